@@ -1,8 +1,185 @@
 package sugar
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+var (
+	// ErrKindConflict is returned when registering a kind twice in a Factory.
+	ErrKindConflict = errors.New("sugar: kind already registered")
+	// ErrUnknownKind is returned when creating a kind no constructor is registered for.
+	ErrUnknownKind = errors.New("sugar: unknown kind")
+	// ErrFactoryArg is returned by FactoryArg for a missing or mistyped constructor argument.
+	ErrFactoryArg = errors.New("sugar: invalid constructor argument")
+)
+
 type Product interface {
 }
 
 type AbstractFactory interface {
 	Create() Product
 }
+
+// Constructor builds a T from the arguments passed to Factory.Create.
+type Constructor[T any] func(args ...any) (T, error)
+
+// FactoryOption configures a kind registered in a Factory.
+type FactoryOption func(*factoryConfig)
+
+type factoryConfig struct {
+	singleton bool
+}
+
+// FactorySingleton makes the kind a lazy singleton: the constructor runs on the first successful
+// Create, with its arguments, and later calls return the same instance, ignoring their arguments.
+func FactorySingleton() FactoryOption {
+	return func(c *factoryConfig) {
+		c.singleton = true
+	}
+}
+
+type factoryEntry[T any] struct {
+	constructor Constructor[T]
+	config      factoryConfig
+
+	mu       sync.Mutex
+	instance T
+	created  bool
+}
+
+// Factory is a registry of constructors keyed by kind, typically a name found in configuration.
+// Implementations can register themselves from an init function:
+//
+//	var Codecs = sugar.NewFactory[string, Codec]()
+//
+//	func init() {
+//		Codecs.MustRegister("json", newJSONCodec)
+//	}
+//
+// It is safe for concurrent use.
+type Factory[K comparable, T any] struct {
+	mu      sync.RWMutex
+	entries map[K]*factoryEntry[T]
+	kinds   []K
+}
+
+// NewFactory returns an empty Factory.
+func NewFactory[K comparable, T any]() *Factory[K, T] {
+	return &Factory[K, T]{
+		entries: make(map[K]*factoryEntry[T]),
+	}
+}
+
+// Register registers constructor for kind. It returns ErrKindConflict if kind is already registered.
+// By default every Create builds a new instance, see FactorySingleton.
+func (f *Factory[K, T]) Register(kind K, constructor Constructor[T], opts ...FactoryOption) error {
+	var config factoryConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.entries[kind]; ok {
+		return fmt.Errorf("%w: %v", ErrKindConflict, kind)
+	}
+	f.entries[kind] = &factoryEntry[T]{constructor: constructor, config: config}
+	f.kinds = append(f.kinds, kind)
+	return nil
+}
+
+// MustRegister is like Register but panics on conflict, for registration from init functions.
+func (f *Factory[K, T]) MustRegister(kind K, constructor Constructor[T], opts ...FactoryOption) {
+	if err := f.Register(kind, constructor, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes kind and reports whether it was registered.
+func (f *Factory[K, T]) Unregister(kind K) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.entries[kind]; !ok {
+		return false
+	}
+	delete(f.entries, kind)
+	f.kinds = slices.DeleteFunc(f.kinds, func(k K) bool {
+		return k == kind
+	})
+	return true
+}
+
+// Has reports whether kind is registered.
+func (f *Factory[K, T]) Has(kind K) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	_, ok := f.entries[kind]
+	return ok
+}
+
+// Kinds returns the registered kinds in registration order.
+func (f *Factory[K, T]) Kinds() []K {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.Clone(f.kinds)
+}
+
+// Create returns an instance of kind built with args. Constructor errors are returned wrapped;
+// a failing singleton constructor is retried on the next Create.
+// A constructor must not Create its own kind.
+func (f *Factory[K, T]) Create(kind K, args ...any) (T, error) {
+	f.mu.RLock()
+	entry, ok := f.entries[kind]
+	f.mu.RUnlock()
+
+	var zero T
+	if !ok {
+		return zero, fmt.Errorf("%w: %v", ErrUnknownKind, kind)
+	}
+
+	if !entry.config.singleton {
+		return entry.create(kind, args)
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.created {
+		return entry.instance, nil
+	}
+	instance, err := entry.create(kind, args)
+	if err != nil {
+		return zero, err
+	}
+	entry.instance, entry.created = instance, true
+	return instance, nil
+}
+
+func (e *factoryEntry[T]) create(kind any, args []any) (T, error) {
+	instance, err := e.constructor(args...)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("sugar: creating %v: %w", kind, err)
+	}
+	return instance, nil
+}
+
+// FactoryArg returns the i-th constructor argument as an A, or an error wrapping ErrFactoryArg
+// if it is missing or of another type.
+func FactoryArg[A any](args []any, i int) (A, error) {
+	var zero A
+	if i < 0 || i >= len(args) {
+		return zero, fmt.Errorf("%w: missing argument %d", ErrFactoryArg, i)
+	}
+	v, ok := args[i].(A)
+	if !ok {
+		return zero, fmt.Errorf("%w: argument %d is %T, not %v", ErrFactoryArg, i, args[i], reflect.TypeFor[A]())
+	}
+	return v, nil
+}
@@ -0,0 +1,76 @@
+package sugar
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type prefixGreeter struct {
+	prefix string
+}
+
+func (g *prefixGreeter) Greet(name string) string {
+	return g.prefix + " " + name
+}
+
+var (
+	greeters     = NewFactory[string, greeter]()
+	upperCreated atomic.Int32
+)
+
+func init() {
+	greeters.MustRegister("hello", func(args ...any) (greeter, error) {
+		prefix, err := FactoryArg[string](args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &prefixGreeter{prefix: prefix}, nil
+	})
+	greeters.MustRegister("upper", func(args ...any) (greeter, error) {
+		upperCreated.Add(1)
+		return &prefixGreeter{prefix: strings.ToUpper("hi")}, nil
+	}, FactorySingleton())
+}
+
+func TestFactory(t *testing.T) {
+	g, err := greeters.Create("hello", "hey")
+	if err != nil || g.Greet("bob") != "hey bob" {
+		t.Fatalf("unexpected greeter: %v", err)
+	}
+	if other, _ := greeters.Create("hello", "hey"); other == g {
+		t.Fatal("expected a new instance per call")
+	}
+
+	a, _ := greeters.Create("upper")
+	b, _ := greeters.Create("upper")
+	if a != b || upperCreated.Load() != 1 {
+		t.Fatal("expected a single lazily created instance")
+	}
+
+	if _, err := greeters.Create("hello", 42); !errors.Is(err, ErrFactoryArg) {
+		t.Fatalf("expected argument error, got %v", err)
+	}
+	if _, err := greeters.Create("missing"); !errors.Is(err, ErrUnknownKind) {
+		t.Fatalf("expected unknown kind, got %v", err)
+	}
+	if err := greeters.Register("hello", nil); !errors.Is(err, ErrKindConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if !slices.Equal(greeters.Kinds(), []string{"hello", "upper"}) {
+		t.Fatalf("unexpected kinds %v", greeters.Kinds())
+	}
+}
+
+func TestFactoryArgInterfaceType(t *testing.T) {
+	_, err := FactoryArg[error]([]any{42}, 0)
+	if !errors.Is(err, ErrFactoryArg) || !strings.HasSuffix(err.Error(), "argument 0 is int, not error") {
+		t.Fatalf("expected the interface type in the error, got %v", err)
+	}
+}
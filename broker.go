@@ -0,0 +1,306 @@
+package sugar
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidSubject is returned for empty subjects, empty tokens, or misplaced wildcards.
+	ErrInvalidSubject = errors.New("sugar: invalid subject")
+	// ErrNoResponders is returned by Broker.Request when nobody listens on the subject.
+	ErrNoResponders = errors.New("sugar: no responders for request")
+	// ErrNoReplySubject is returned by Broker.Reply for a message that was not sent with Request.
+	ErrNoReplySubject = errors.New("sugar: message has no reply subject")
+	// ErrPatternReplay is returned by Broker.Subscribe for a wildcard subject with replay options,
+	// which only apply to the history of a single topic.
+	ErrPatternReplay = errors.New("sugar: replay options require a subject without wildcards")
+)
+
+const replyPrefix = "_INBOX."
+
+// ValidateSubject checks that subject is a dot-separated list of non-empty tokens.
+// When wildcards is true, a token may be "*", matching exactly one token, and the
+// last token may be ">", matching one or more trailing tokens.
+func ValidateSubject(subject string, wildcards bool) error {
+	if subject == "" {
+		return ErrInvalidSubject
+	}
+
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		switch {
+		case token == "":
+			return ErrInvalidSubject
+		case token == "*" || token == ">":
+			if !wildcards || (token == ">" && i != len(tokens)-1) {
+				return ErrInvalidSubject
+			}
+		}
+	}
+	return nil
+}
+
+// MatchSubject reports whether subject matches pattern, which may contain "*" and ">" wildcards.
+func MatchSubject(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
+
+func hasWildcard(subject string) bool {
+	return Some(strings.Split(subject, "."), func(token string) bool {
+		return token == "*" || token == ">"
+	})
+}
+
+// Broker manages named topics addressed by hierarchical subjects such as "orders.eu.created".
+// Topics are created on demand; subscribers may use "*" and ">" wildcards to receive from
+// every matching topic, including topics created after they subscribed.
+// It is safe for concurrent use.
+type Broker[T any] struct {
+	topicOpts []TopicOption
+
+	mu        sync.RWMutex
+	topics    map[string]*Topic[T]
+	patterns  map[*Subscription[T]]string
+	pending   map[string]chan Message[T]
+	nextReply uint64
+}
+
+// NewBroker returns an empty Broker. opts are applied to every topic it creates.
+func NewBroker[T any](opts ...TopicOption) *Broker[T] {
+	return &Broker[T]{
+		topicOpts: opts,
+		topics:    make(map[string]*Topic[T]),
+		patterns:  make(map[*Subscription[T]]string),
+		pending:   make(map[string]chan Message[T]),
+	}
+}
+
+// Topic returns the topic for subject, creating it if needed.
+func (b *Broker[T]) Topic(subject string) (*Topic[T], error) {
+	if err := ValidateSubject(subject, false); err != nil {
+		return nil, err
+	}
+
+	b.mu.RLock()
+	topic, ok := b.topics[subject]
+	b.mu.RUnlock()
+	if ok {
+		return topic, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if topic, ok := b.topics[subject]; ok {
+		return topic, nil
+	}
+
+	topic = NewTopic[T](subject, b.topicOpts...)
+	for sub, pattern := range b.patterns {
+		if MatchSubject(pattern, subject) {
+			topic.attach(sub)
+		}
+	}
+	b.topics[subject] = topic
+	return topic, nil
+}
+
+// Topics returns the subjects of the existing topics.
+func (b *Broker[T]) Topics() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return Keys(b.topics)
+}
+
+// Publish publishes payload on the topic for subject, creating it if needed.
+func (b *Broker[T]) Publish(subject string, payload T) error {
+	topic, err := b.Topic(subject)
+	if err != nil {
+		return err
+	}
+	return topic.Publish(payload)
+}
+
+// Subscribe subscribes to subject, which may be a wildcard pattern. A pattern subscription
+// only receives live messages: it returns ErrPatternReplay with SubscriptionReplay,
+// SubscriptionFromOffset or SubscriptionSince.
+func (b *Broker[T]) Subscribe(subject string, uid uint64, name string, opts ...SubscriptionOption) (*Subscription[T], error) {
+	if err := ValidateSubject(subject, true); err != nil {
+		return nil, err
+	}
+
+	if !hasWildcard(subject) {
+		topic, err := b.Topic(subject)
+		if err != nil {
+			return nil, err
+		}
+		return topic.Subscribe(uid, name, opts...)
+	}
+
+	config := newSubscriptionConfig(opts)
+	if config.replay != 0 || config.from > 0 || !config.since.IsZero() {
+		return nil, ErrPatternReplay
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := newSubscription[T](b, uid, name, config, nil)
+	b.patterns[sub] = subject
+	for existing, topic := range b.topics {
+		if MatchSubject(subject, existing) {
+			topic.attach(sub)
+		}
+	}
+	return sub, nil
+}
+
+// remove detaches a pattern subscription from every topic.
+func (b *Broker[T]) remove(sub *Subscription[T]) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.patterns[sub]; !ok {
+		return false
+	}
+	delete(b.patterns, sub)
+	for _, topic := range b.topics {
+		topic.remove(sub)
+	}
+	return true
+}
+
+// DeleteTopic deletes the topic for subject and notifies its subscribers, see Topic.Delete.
+// Pattern subscriptions stay active for the other topics.
+func (b *Broker[T]) DeleteTopic(subject string) error {
+	b.mu.Lock()
+	topic, ok := b.topics[subject]
+	delete(b.topics, subject)
+	b.mu.Unlock()
+
+	if !ok {
+		return ErrTopicClosed
+	}
+	return topic.Delete()
+}
+
+// Stats returns the counters of every topic keyed by subject.
+func (b *Broker[T]) Stats() map[string]TopicStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return MapValues(b.topics, func(_ string, topic *Topic[T]) TopicStats {
+		return topic.Stats()
+	})
+}
+
+// Close closes every topic, waiting for subscribers to drain as Topic.Close does,
+// and ends all pattern subscriptions.
+func (b *Broker[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	topics := Values(b.topics)
+	patterns := Keys(b.patterns)
+	b.topics = make(map[string]*Topic[T])
+	b.patterns = make(map[*Subscription[T]]string)
+	b.mu.Unlock()
+
+	var errs []error
+	for _, topic := range topics {
+		if err := topic.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, sub := range patterns {
+		sub.close(nil)
+	}
+	return errors.Join(errs...)
+}
+
+// Request publishes payload on subject with a private ReplyTo subject and waits for the
+// first reply, sent with Reply, until timeout elapses or ctx is done. A timeout of zero or
+// less waits on ctx alone. It returns
+// ErrNoResponders, without creating the topic, if no topic exists for subject.
+func (b *Broker[T]) Request(ctx context.Context, subject string, payload T, timeout time.Duration) (Message[T], error) {
+	var zero Message[T]
+
+	if err := ValidateSubject(subject, false); err != nil {
+		return zero, err
+	}
+	b.mu.RLock()
+	topic, ok := b.topics[subject]
+	b.mu.RUnlock()
+	if !ok || topic.Stats().Subscribers == 0 {
+		return zero, ErrNoResponders
+	}
+
+	replies := make(chan Message[T], 1)
+	b.mu.Lock()
+	b.nextReply++
+	replyTo := replyPrefix + strconv.FormatUint(b.nextReply, 10)
+	b.pending[replyTo] = replies
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.pending, replyTo)
+		b.mu.Unlock()
+	}()
+
+	if err := topic.publish(payload, replyTo); err != nil {
+		return zero, err
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-ctx.Done():
+		return zero, context.Cause(ctx)
+	}
+}
+
+// Reply answers a message received from Request. Only the first reply is delivered;
+// replies arriving after the requester gave up are discarded.
+func (b *Broker[T]) Reply(request Message[T], payload T) error {
+	if request.ReplyTo == "" {
+		return ErrNoReplySubject
+	}
+
+	b.mu.RLock()
+	replies, ok := b.pending[request.ReplyTo]
+	b.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	select {
+	case replies <- Message[T]{
+		Topic:     request.ReplyTo,
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}:
+	default:
+	}
+	return nil
+}
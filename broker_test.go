@@ -0,0 +1,111 @@
+package sugar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMatchSubject(t *testing.T) {
+	cases := []struct {
+		pattern, subject string
+		want             bool
+	}{
+		{"orders.*.created", "orders.eu.created", true},
+		{"orders.*.created", "orders.eu.deleted", false},
+		{"orders.>", "orders.eu.created", true},
+		{"orders.>", "orders", false},
+		{"orders.*", "orders.eu.created", false},
+	}
+	for _, c := range cases {
+		if got := MatchSubject(c.pattern, c.subject); got != c.want {
+			t.Fatalf("MatchSubject(%q, %q) = %v", c.pattern, c.subject, got)
+		}
+	}
+
+	if ValidateSubject("orders.>.created", true) == nil {
+		t.Fatal("expected > in the middle to be rejected")
+	}
+}
+
+func TestBrokerWildcardSubscription(t *testing.T) {
+	broker := NewBroker[string]()
+	sub, err := broker.Subscribe("orders.*.created", 1, "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broker.Publish("orders.eu.created", "a")
+	broker.Publish("orders.eu.deleted", "b")
+	broker.Publish("orders.us.created", "c")
+
+	if msg := <-sub.Inbox; msg.Payload != "a" || msg.Topic != "orders.eu.created" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if msg := <-sub.Inbox; msg.Payload != "c" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	stats := broker.Stats()
+	if stats["orders.eu.created"].Subscribers != 1 || stats["orders.eu.deleted"].Published != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	if _, err := broker.Subscribe("orders.>", 2, "replay", SubscriptionReplay(-1)); !errors.Is(err, ErrPatternReplay) {
+		t.Fatalf("expected ErrPatternReplay, got %v", err)
+	}
+}
+
+func TestBrokerDeleteTopicNotifies(t *testing.T) {
+	broker := NewBroker[int]()
+	deleted := make(chan string, 1)
+	direct, _ := broker.Subscribe("jobs.a", 1, "direct")
+	pattern, _ := broker.Subscribe("jobs.>", 2, "pattern", SubscriptionOnTopicDeleted(func(topic string) {
+		deleted <- topic
+	}))
+
+	broker.DeleteTopic("jobs.a")
+	if _, ok := <-direct.Inbox; ok || !errors.Is(direct.Err(), ErrTopicDeleted) {
+		t.Fatalf("expected direct subscription to end, got %v", direct.Err())
+	}
+	if topic := <-deleted; topic != "jobs.a" {
+		t.Fatalf("unexpected deleted topic %s", topic)
+	}
+
+	broker.Publish("jobs.b", 7)
+	if msg := <-pattern.Inbox; msg.Payload != 7 {
+		t.Fatalf("expected pattern subscription to stay active, got %+v", msg)
+	}
+}
+
+func TestBrokerRequestReply(t *testing.T) {
+	broker := NewBroker[string]()
+	if _, err := broker.Request(context.Background(), "echo", "x", time.Second); !errors.Is(err, ErrNoResponders) {
+		t.Fatalf("expected ErrNoResponders, got %v", err)
+	}
+	if topics := broker.Topics(); len(topics) != 0 {
+		t.Fatalf("expected Request not to create topics, got %v", topics)
+	}
+
+	sub, _ := broker.Subscribe("echo", 1, "responder")
+	go func() {
+		for msg := range sub.Inbox {
+			broker.Reply(msg, "re: "+msg.Payload)
+		}
+	}()
+
+	reply, err := broker.Request(context.Background(), "echo", "hi", time.Second)
+	if err != nil || reply.Payload != "re: hi" {
+		t.Fatalf("unexpected reply %+v, %v", reply, err)
+	}
+	if reply, err := broker.Request(context.Background(), "echo", "again", 0); err != nil || reply.Payload != "re: again" {
+		t.Fatalf("expected no timeout to wait for the reply, got %+v, %v", reply, err)
+	}
+
+	silent, _ := broker.Subscribe("silent", 2, "mute")
+	defer silent.Unsubscribe()
+	if _, err := broker.Request(context.Background(), "silent", "hi", 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+}
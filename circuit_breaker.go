@@ -0,0 +1,428 @@
+package sugar
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCircuitOpen is returned when a call is rejected because the breaker is open.
+	ErrCircuitOpen = errors.New("sugar: circuit breaker is open")
+	// ErrTooManyProbes is returned when a half-open breaker already has all its probe calls in flight.
+	ErrTooManyProbes = errors.New("sugar: too many requests while circuit breaker is half-open")
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitCounts is a snapshot of the calls recorded in the breaker's rolling window.
+type CircuitCounts struct {
+	Requests            int
+	Successes           int
+	Failures            int
+	ConsecutiveFailures int
+}
+
+// FailureRate returns Failures/Requests, or 0 when there were no requests.
+func (c CircuitCounts) FailureRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Requests)
+}
+
+// CircuitBreakerOption configures a CircuitBreaker.
+type CircuitBreakerOption func(*circuitBreakerConfig)
+
+type circuitBreakerConfig struct {
+	consecutiveFailures int
+	failureRate         float64
+	minRequests         int
+	window              time.Duration
+	buckets             int
+	cooldown            time.Duration
+	halfOpenRequests    int
+	isFailure           func(error) bool
+	onStateChange       []func(from, to CircuitState)
+	clock               Clock
+}
+
+// CircuitConsecutiveFailures trips the breaker after n failures in a row. 0 disables the policy. Defaults to 5.
+func CircuitConsecutiveFailures(n int) CircuitBreakerOption {
+	return func(c *circuitBreakerConfig) {
+		c.consecutiveFailures = n
+	}
+}
+
+// CircuitFailureRate trips the breaker once the rolling window holds at least minRequests calls
+// and the share of failures among them reaches rate (0 < rate <= 1).
+func CircuitFailureRate(rate float64, minRequests int) CircuitBreakerOption {
+	return func(c *circuitBreakerConfig) {
+		c.failureRate = rate
+		c.minRequests = minRequests
+	}
+}
+
+// CircuitWindow sets the length of the rolling window and the number of buckets it is split into.
+// Defaults to 10s in 10 buckets.
+func CircuitWindow(window time.Duration, buckets int) CircuitBreakerOption {
+	return func(c *circuitBreakerConfig) {
+		c.window = window
+		c.buckets = buckets
+	}
+}
+
+// CircuitCooldown sets how long the breaker stays open before letting probe calls through. Defaults to 30s.
+func CircuitCooldown(d time.Duration) CircuitBreakerOption {
+	return func(c *circuitBreakerConfig) {
+		c.cooldown = d
+	}
+}
+
+// CircuitHalfOpenRequests sets how many probe calls are let through while half-open;
+// that many successes close the breaker again. Defaults to 1.
+func CircuitHalfOpenRequests(n int) CircuitBreakerOption {
+	return func(c *circuitBreakerConfig) {
+		c.halfOpenRequests = n
+	}
+}
+
+// CircuitIsFailure sets the classifier deciding which errors count as failures.
+// By default every non-nil error does.
+func CircuitIsFailure(isFailure func(error) bool) CircuitBreakerOption {
+	return func(c *circuitBreakerConfig) {
+		c.isFailure = isFailure
+	}
+}
+
+// CircuitOnStateChange registers a callback invoked after every state transition.
+func CircuitOnStateChange(callback func(from, to CircuitState)) CircuitBreakerOption {
+	return func(c *circuitBreakerConfig) {
+		c.onStateChange = append(c.onStateChange, callback)
+	}
+}
+
+// CircuitClock sets the Clock used for the cooldown and the rolling window.
+func CircuitClock(clock Clock) CircuitBreakerOption {
+	return func(c *circuitBreakerConfig) {
+		c.clock = clock
+	}
+}
+
+// CircuitBreaker stops calling a failing downstream for a cooldown period once a trip policy
+// fires, then lets a limited number of probe calls through to decide whether to close again.
+// It is safe for concurrent use.
+type CircuitBreaker struct {
+	config circuitBreakerConfig
+
+	mu          sync.Mutex
+	state       CircuitState
+	generation  uint64
+	openedAt    time.Time
+	window      *rollingWindow
+	consecutive int
+	probes      int
+	probeOK     int
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker.
+func NewCircuitBreaker(opts ...CircuitBreakerOption) *CircuitBreaker {
+	config := circuitBreakerConfig{
+		consecutiveFailures: 5,
+		window:              10 * time.Second,
+		buckets:             10,
+		cooldown:            30 * time.Second,
+		halfOpenRequests:    1,
+		isFailure:           func(err error) bool { return err != nil },
+		clock:               SystemClock(),
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.buckets <= 0 {
+		config.buckets = 1
+	}
+	if config.halfOpenRequests <= 0 {
+		config.halfOpenRequests = 1
+	}
+
+	return &CircuitBreaker{
+		config: config,
+		window: newRollingWindow(config.window, config.buckets, config.clock.Now()),
+	}
+}
+
+// State returns the current state, moving from open to half-open if the cooldown has elapsed.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	changes := cb.refresh(cb.config.clock.Now())
+	state := cb.state
+	cb.mu.Unlock()
+
+	cb.notify(changes)
+	return state
+}
+
+// Counts returns the calls recorded in the current rolling window.
+func (cb *CircuitBreaker) Counts() CircuitCounts {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	counts := cb.window.counts(cb.config.clock.Now())
+	counts.ConsecutiveFailures = cb.consecutive
+	return counts
+}
+
+// Reset forces the breaker back to closed and clears its counters.
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	changes := cb.setState(CircuitClosed, cb.config.clock.Now())
+	cb.mu.Unlock()
+
+	cb.notify(changes)
+}
+
+// Execute calls f if the breaker allows it and records the outcome.
+// It returns ErrCircuitOpen or ErrTooManyProbes without calling f otherwise.
+func (cb *CircuitBreaker) Execute(f func() error) error {
+	_, err := CircuitCall(cb, func() (struct{}, error) {
+		return struct{}{}, f()
+	})
+	return err
+}
+
+// CircuitCall calls f through cb and records the outcome. A panic in f is recorded
+// as a failure and re-raised.
+func CircuitCall[T any](cb *CircuitBreaker, f func() (T, error)) (T, error) {
+	generation, err := cb.before()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	succeeded := false
+	defer func() {
+		if r := recover(); r != nil {
+			cb.after(generation, false)
+			panic(r)
+		}
+		cb.after(generation, succeeded)
+	}()
+
+	value, err := f()
+	succeeded = !cb.config.isFailure(err)
+	return value, err
+}
+
+// CircuitWrap returns f guarded by cb. Combined with RetryValue it lets retries
+// back off while the breaker is open instead of hammering the downstream:
+//
+//	call := CircuitWrap(cb, fetch)
+//	v, err := RetryValue(ctx, func(context.Context, int) (T, error) { return call() })
+func CircuitWrap[T any](cb *CircuitBreaker, f func() (T, error)) func() (T, error) {
+	return func() (T, error) {
+		return CircuitCall(cb, f)
+	}
+}
+
+func (cb *CircuitBreaker) before() (uint64, error) {
+	cb.mu.Lock()
+	changes := cb.refresh(cb.config.clock.Now())
+
+	var err error
+	switch cb.state {
+	case CircuitOpen:
+		err = ErrCircuitOpen
+	case CircuitHalfOpen:
+		if cb.probes >= cb.config.halfOpenRequests {
+			err = ErrTooManyProbes
+		} else {
+			cb.probes++
+		}
+	}
+	generation := cb.generation
+	cb.mu.Unlock()
+
+	cb.notify(changes)
+	return generation, err
+}
+
+func (cb *CircuitBreaker) after(generation uint64, success bool) {
+	cb.mu.Lock()
+	now := cb.config.clock.Now()
+	changes := cb.refresh(now)
+
+	// Results of calls started before the last transition belong to an old generation.
+	if generation != cb.generation {
+		cb.mu.Unlock()
+		cb.notify(changes)
+		return
+	}
+
+	switch cb.state {
+	case CircuitClosed:
+		cb.window.record(now, success)
+		if success {
+			cb.consecutive = 0
+		} else {
+			cb.consecutive++
+			if cb.shouldTrip(now) {
+				changes = append(changes, cb.setState(CircuitOpen, now)...)
+			}
+		}
+	case CircuitHalfOpen:
+		if !success {
+			changes = append(changes, cb.setState(CircuitOpen, now)...)
+			break
+		}
+		cb.probeOK++
+		if cb.probeOK >= cb.config.halfOpenRequests {
+			changes = append(changes, cb.setState(CircuitClosed, now)...)
+		}
+	}
+	cb.mu.Unlock()
+
+	cb.notify(changes)
+}
+
+func (cb *CircuitBreaker) shouldTrip(now time.Time) bool {
+	if cb.config.consecutiveFailures > 0 && cb.consecutive >= cb.config.consecutiveFailures {
+		return true
+	}
+	if cb.config.failureRate > 0 {
+		counts := cb.window.counts(now)
+		return counts.Requests >= cb.config.minRequests && counts.FailureRate() >= cb.config.failureRate
+	}
+	return false
+}
+
+// refresh must be called with the lock held.
+func (cb *CircuitBreaker) refresh(now time.Time) []circuitTransition {
+	if cb.state == CircuitOpen && !now.Before(cb.openedAt.Add(cb.config.cooldown)) {
+		return cb.setState(CircuitHalfOpen, now)
+	}
+	return nil
+}
+
+// setState must be called with the lock held.
+func (cb *CircuitBreaker) setState(state CircuitState, now time.Time) []circuitTransition {
+	from := cb.state
+	cb.state = state
+	cb.generation++
+	cb.probes = 0
+	cb.probeOK = 0
+
+	switch state {
+	case CircuitOpen:
+		cb.openedAt = now
+	case CircuitClosed:
+		cb.consecutive = 0
+		cb.window.reset(now)
+	}
+
+	if from == state {
+		return nil
+	}
+	return []circuitTransition{{from, state}}
+}
+
+func (cb *CircuitBreaker) notify(changes []circuitTransition) {
+	for _, change := range changes {
+		for _, callback := range cb.config.onStateChange {
+			callback(change.from, change.to)
+		}
+	}
+}
+
+type circuitTransition struct {
+	from, to CircuitState
+}
+
+type windowBucket struct {
+	successes int
+	failures  int
+}
+
+// rollingWindow counts outcomes over the last window split into fixed-size buckets.
+type rollingWindow struct {
+	buckets    []windowBucket
+	bucketSize time.Duration
+	head       int
+	headStart  time.Time
+}
+
+func newRollingWindow(window time.Duration, buckets int, now time.Time) *rollingWindow {
+	bucketSize := window / time.Duration(buckets)
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	return &rollingWindow{
+		buckets:    make([]windowBucket, buckets),
+		bucketSize: bucketSize,
+		headStart:  now,
+	}
+}
+
+func (w *rollingWindow) reset(now time.Time) {
+	clear(w.buckets)
+	w.head = 0
+	w.headStart = now
+}
+
+func (w *rollingWindow) advance(now time.Time) {
+	elapsed := int(now.Sub(w.headStart) / w.bucketSize)
+	if elapsed <= 0 {
+		return
+	}
+	if elapsed >= len(w.buckets) {
+		w.reset(now)
+		return
+	}
+
+	for i := 0; i < elapsed; i++ {
+		w.head = (w.head + 1) % len(w.buckets)
+		w.buckets[w.head] = windowBucket{}
+	}
+	w.headStart = w.headStart.Add(time.Duration(elapsed) * w.bucketSize)
+}
+
+func (w *rollingWindow) record(now time.Time, success bool) {
+	w.advance(now)
+	if success {
+		w.buckets[w.head].successes++
+	} else {
+		w.buckets[w.head].failures++
+	}
+}
+
+func (w *rollingWindow) counts(now time.Time) CircuitCounts {
+	w.advance(now)
+
+	var counts CircuitCounts
+	for _, b := range w.buckets {
+		counts.Successes += b.successes
+		counts.Failures += b.failures
+	}
+	counts.Requests = counts.Successes + counts.Failures
+	return counts
+}
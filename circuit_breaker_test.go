@@ -0,0 +1,91 @@
+package sugar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	var transitions []string
+	cb := NewCircuitBreaker(
+		CircuitConsecutiveFailures(2),
+		CircuitCooldown(time.Second),
+		CircuitClock(clock),
+		CircuitOnStateChange(func(from, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		}),
+	)
+
+	down := errors.New("down")
+	fail := func() (int, error) { return 0, down }
+	ok := func() (int, error) { return 1, nil }
+
+	CircuitCall(cb, fail)
+	CircuitCall(cb, fail)
+	if cb.State() != CircuitOpen {
+		t.Fatalf("expected open, got %v", cb.State())
+	}
+	if _, err := CircuitCall(cb, ok); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("expected half-open, got %v", cb.State())
+	}
+	CircuitCall(cb, fail)
+	if cb.State() != CircuitOpen {
+		t.Fatalf("expected failed probe to reopen, got %v", cb.State())
+	}
+
+	clock.Advance(time.Second)
+	if v, err := CircuitCall(cb, ok); err != nil || v != 1 {
+		t.Fatalf("unexpected probe result %d, %v", v, err)
+	}
+	if cb.State() != CircuitClosed {
+		t.Fatalf("expected closed, got %v", cb.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("unexpected transitions %v", transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("unexpected transitions %v", transitions)
+		}
+	}
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	cb := NewCircuitBreaker(
+		CircuitConsecutiveFailures(0),
+		CircuitFailureRate(0.5, 4),
+		CircuitWindow(time.Second, 4),
+		CircuitClock(clock),
+	)
+
+	down := errors.New("down")
+	cb.Execute(func() error { return down })
+	cb.Execute(func() error { return nil })
+	cb.Execute(func() error { return down })
+	if cb.State() != CircuitClosed {
+		t.Fatal("expected breaker to wait for minimum requests")
+	}
+
+	clock.Advance(2 * time.Second)
+	cb.Execute(func() error { return down })
+	if cb.State() != CircuitClosed {
+		t.Fatalf("expected old failures to roll out of the window, got %+v", cb.Counts())
+	}
+
+	cb.Execute(func() error { return nil })
+	cb.Execute(func() error { return down })
+	cb.Execute(func() error { return down })
+	if cb.State() != CircuitOpen {
+		t.Fatalf("expected open, got %v with %+v", cb.State(), cb.Counts())
+	}
+}
@@ -0,0 +1,148 @@
+package sugar
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passage of time so time-based helpers can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is the subset of *time.Timer returned by Clock.AfterFunc.
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+type systemClock struct{}
+
+// SystemClock returns the Clock backed by the time package.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// FakeClock is a Clock that only moves when told to. Timers fire synchronously,
+// in deadline order, from the goroutine calling Advance or Set.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the current fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the fake time once it has advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.AfterFunc(d, func() {
+		ch <- c.Now()
+	})
+	return ch
+}
+
+// AfterFunc calls f once the fake time has advanced by d.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, fn: f, when: c.now.Add(d)}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the fake time forward by d, firing every timer that becomes due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the fake time to t, firing every timer that becomes due.
+func (c *FakeClock) Set(t time.Time) {
+	for {
+		c.mu.Lock()
+		sort.SliceStable(c.timers, func(i, j int) bool {
+			return c.timers[i].when.Before(c.timers[j].when)
+		})
+
+		if len(c.timers) == 0 || c.timers[0].when.After(t) {
+			c.now = t
+			c.mu.Unlock()
+			return
+		}
+
+		next := c.timers[0]
+		c.timers = c.timers[1:]
+		if next.when.After(c.now) {
+			c.now = next.when
+		}
+		c.mu.Unlock()
+
+		next.fn()
+	}
+}
+
+// PendingTimers returns the number of timers that have not fired or been stopped yet.
+func (c *FakeClock) PendingTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	fn    func()
+	when  time.Time
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.remove()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.remove()
+	t.when = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+	return active
+}
+
+// remove must be called with the clock lock held.
+func (t *fakeTimer) remove() bool {
+	for i, other := range t.clock.timers {
+		if other == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
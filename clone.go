@@ -0,0 +1,174 @@
+package sugar
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	copiersMu sync.RWMutex
+	copiers   = make(map[reflect.Type]func(reflect.Value) reflect.Value)
+)
+
+// RegisterCopier makes DeepCopy copy every value of type T with copier instead of by reflection,
+// e.g. for types holding handles that must not be duplicated. It replaces any previous copier for T.
+func RegisterCopier[T any](copier func(T) T) {
+	copiersMu.Lock()
+	defer copiersMu.Unlock()
+
+	copiers[reflect.TypeFor[T]()] = func(v reflect.Value) reflect.Value {
+		return reflect.ValueOf(copier(v.Interface().(T)))
+	}
+}
+
+// UnregisterCopier removes the copier registered for T.
+func UnregisterCopier[T any]() {
+	copiersMu.Lock()
+	defer copiersMu.Unlock()
+	delete(copiers, reflect.TypeFor[T]())
+}
+
+func lookupCopier(t reflect.Type) func(reflect.Value) reflect.Value {
+	copiersMu.RLock()
+	defer copiersMu.RUnlock()
+	return copiers[t]
+}
+
+// DeepCopy returns a copy of v sharing no memory with it: pointers, slices, maps, arrays,
+// interfaces and the exported fields of structs are copied recursively, and values reachable
+// several times, including through cycles, are copied once. Unexported struct fields, channels
+// and functions are copied as is.
+//
+// Types with a copier registered with RegisterCopier are copied with it. Exported struct fields
+// tagged `sugar:"-"` are left zero in the copy and those tagged `sugar:"shallow"` are assigned
+// without being copied.
+func DeepCopy[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	newDeepCloner().copy(dst, src)
+	return dst.Interface().(T)
+}
+
+// DeepClone is DeepCopy, the fallback PrototypeManager uses for prototypes that are not a Cloner.
+func DeepClone[T any](v T) T {
+	return DeepCopy(v)
+}
+
+// cloneKey identifies an already copied pointer, slice or map. Slices also need their length,
+// capacity and type since several slices may share the same backing array.
+type cloneKey struct {
+	ptr uintptr
+	len int
+	cap int
+	typ reflect.Type
+}
+
+type deepCloner struct {
+	visited map[cloneKey]reflect.Value
+}
+
+func newDeepCloner() *deepCloner {
+	return &deepCloner{visited: make(map[cloneKey]reflect.Value)}
+}
+
+// copy sets dst, which must be settable, to a deep copy of src.
+func (c *deepCloner) copy(dst, src reflect.Value) {
+	if src.Kind() != reflect.Interface && src.CanInterface() {
+		if copier := lookupCopier(src.Type()); copier != nil {
+			dst.Set(copier(src))
+			return
+		}
+	}
+
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		key := cloneKey{ptr: src.Pointer(), typ: src.Type()}
+		if copied, ok := c.visited[key]; ok {
+			dst.Set(copied)
+			return
+		}
+		ptr := reflect.New(src.Type().Elem())
+		c.visited[key] = ptr
+		c.copy(ptr.Elem(), src.Elem())
+		dst.Set(ptr)
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		c.copy(elem, src.Elem())
+		dst.Set(elem)
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		key := cloneKey{ptr: src.Pointer(), len: src.Len(), cap: src.Cap(), typ: src.Type()}
+		if copied, ok := c.visited[key]; ok {
+			dst.Set(copied)
+			return
+		}
+		slice := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		c.visited[key] = slice
+		for i := 0; i < src.Len(); i++ {
+			c.copy(slice.Index(i), src.Index(i))
+		}
+		dst.Set(slice)
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		key := cloneKey{ptr: src.Pointer(), typ: src.Type()}
+		if copied, ok := c.visited[key]; ok {
+			dst.Set(copied)
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		c.visited[key] = m
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			c.copy(k, iter.Key())
+			v := reflect.New(src.Type().Elem()).Elem()
+			c.copy(v, iter.Value())
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			field := dst.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			switch structTag(src.Type().Field(i)) {
+			case "-":
+				field.SetZero()
+			case "shallow":
+			default:
+				c.copy(field, src.Field(i))
+			}
+		}
+
+	default:
+		dst.Set(src)
+	}
+}
+
+// structTag returns the option of the sugar tag of field, "-" or "shallow".
+func structTag(field reflect.StructField) string {
+	tag, _, _ := strings.Cut(field.Tag.Get("sugar"), ",")
+	return tag
+}
@@ -0,0 +1,109 @@
+package sugar
+
+import (
+	"strings"
+	"testing"
+)
+
+type copyHandle struct {
+	ID int
+}
+
+type copyConfig struct {
+	Name    string
+	Hosts   map[string][]string
+	Parent  *copyConfig
+	Cache   map[string]int `sugar:"-"`
+	Shared  *[]int         `sugar:"shallow"`
+	Handle  *copyHandle
+	Payload any
+	secret  string
+}
+
+func TestDeepCopy(t *testing.T) {
+	RegisterCopier(func(h *copyHandle) *copyHandle {
+		return &copyHandle{ID: -h.ID}
+	})
+	defer UnregisterCopier[*copyHandle]()
+
+	shared := []int{1}
+	src := &copyConfig{
+		Name:    "a",
+		Hosts:   map[string][]string{"eu": {"h1"}},
+		Cache:   map[string]int{"x": 1},
+		Shared:  &shared,
+		Handle:  &copyHandle{ID: 7},
+		Payload: []int{1, 2},
+		secret:  "s",
+	}
+	src.Parent = src
+
+	dst := DeepCopy(src)
+	dst.Hosts["eu"][0] = "h2"
+	dst.Payload.([]int)[0] = 9
+
+	if src.Hosts["eu"][0] != "h1" || src.Payload.([]int)[0] != 1 {
+		t.Fatal("expected nested values to be copied")
+	}
+	if dst.Parent != dst || dst.Cache != nil || dst.Shared != src.Shared || dst.secret != "s" {
+		t.Fatalf("unexpected copy %+v", dst)
+	}
+	if dst.Handle.ID != -7 {
+		t.Fatalf("expected the registered copier to be used, got %d", dst.Handle.ID)
+	}
+}
+
+func TestDeepCopyOverlappingSubslices(t *testing.T) {
+	backing := []int{1, 2, 3}
+	src := [][]int{backing[:2:2], backing[:2], backing[1:]}
+
+	dst := DeepCopy(src)
+	for i := range src {
+		if len(dst[i]) != len(src[i]) || cap(dst[i]) != cap(src[i]) {
+			t.Fatalf("subslice %d: expected len %d cap %d, got len %d cap %d",
+				i, len(src[i]), cap(src[i]), len(dst[i]), cap(dst[i]))
+		}
+	}
+	if !DeepEqual(src, dst) {
+		t.Fatalf("expected an equal copy:\n%s", DeepDiff(src, dst))
+	}
+}
+
+func TestDeepDiff(t *testing.T) {
+	a := copyConfig{Name: "a", Hosts: map[string][]string{"eu": {"h1"}, "us": {"h3"}}, Cache: map[string]int{"x": 1}}
+	b := DeepCopy(a)
+	if !DeepEqual(a, b) {
+		t.Fatalf("expected copies to be equal:\n%s", DeepDiff(a, b))
+	}
+
+	b.Hosts["eu"] = append(b.Hosts["eu"], "h2")
+	delete(b.Hosts, "us")
+	b.secret = "changed"
+
+	want := strings.Join([]string{
+		`$.Hosts["eu"][1]: <missing> != "h2"`,
+		`$.Hosts["us"]: [h3] != <missing>`,
+		`$.secret: "" != "changed"`,
+	}, "\n")
+	if got := DeepDiff(a, b).String(); got != want {
+		t.Fatalf("unexpected report:\n%s", got)
+	}
+}
+
+func TestDeepDiffSharedAddresses(t *testing.T) {
+	x, y := []int{1, 2}, []int{1, 3}
+	a, b := [][]int{x[:1], x[:2]}, [][]int{y[:1], y[:2]}
+	if got := DeepDiff(a, b).String(); got != "$[1][1]: 2 != 3" {
+		t.Fatalf("expected overlapping subslices to be compared, got %q", got)
+	}
+
+	type pair struct{ N, M int }
+	type refs struct {
+		First *int
+		Pair  *pair
+	}
+	pa, pb := &pair{N: 1, M: 1}, &pair{N: 1, M: 2}
+	if DeepEqual(refs{&pa.N, pa}, refs{&pb.N, pb}) {
+		t.Fatal("expected a struct to be compared after its first field")
+	}
+}
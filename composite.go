@@ -1,14 +1,39 @@
 package sugar
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+)
+
+var (
+	// ErrTreeCycle is returned when adding a node under itself or one of its descendants.
+	ErrTreeCycle = errors.New("sugar: node cannot become its own descendant")
+	// ErrTreeIndex is returned by Composite.InsertChild for a position outside the children.
+	ErrTreeIndex = errors.New("sugar: child index out of range")
+)
 
-type Component interface {
-	Parent() Component
-	SetParent(Component)
+// Component is a node of a tree made of Leaf and Composite nodes carrying a value of type T.
+type Component[T any] interface {
+	// Parent returns the composite holding the node, or nil for a root.
+	Parent() *Composite[T]
 	Name() string
 	SetName(string)
-	AddChild(Component)
+	Value() T
+	SetValue(T)
+	// Depth returns the number of ancestors of the node.
+	Depth() int
+	// Path returns the names of the nodes from the root down to this one.
+	Path() []string
+	// Fprint writes the subtree to w, one node per line prefixed by pre, "+" marking composites and "-" leaves.
+	Fprint(w io.Writer, pre string) error
+	// Print is Fprint to os.Stdout.
 	Print(string)
+
+	setParent(*Composite[T])
 }
 
 const (
@@ -16,76 +41,228 @@ const (
 	CompositeNode
 )
 
-func NewComponent(kind int, name string) Component {
-	var c Component
+// NewComponent returns a Leaf or a Composite, depending on kind, holding the zero value.
+func NewComponent[T any](kind int, name string) Component[T] {
+	var zero T
 	switch kind {
 	case LeafNode:
-		c = NewLeaf()
+		return NewLeaf(name, zero)
 	case CompositeNode:
-		c = NewComposite()
+		return NewComposite(name, zero)
 	}
-
-	c.SetName(name)
-	return c
+	panic(fmt.Sprintf("sugar: unknown component kind %d", kind))
 }
 
-type component struct {
-	parent Component
+type component[T any] struct {
+	parent *Composite[T]
 	name   string
+	value  T
 }
 
-func (c *component) Parent() Component {
+func (c *component[T]) Parent() *Composite[T] {
 	return c.parent
 }
 
-func (c *component) SetParent(parent Component) {
+func (c *component[T]) setParent(parent *Composite[T]) {
 	c.parent = parent
 }
 
-func (c *component) Name() string {
+func (c *component[T]) Name() string {
 	return c.name
 }
 
-func (c *component) SetName(name string) {
+func (c *component[T]) SetName(name string) {
 	c.name = name
 }
 
-func (c *component) AddChild(Component) {}
+func (c *component[T]) Value() T {
+	return c.value
+}
 
-func (c *component) Print(string) {}
+func (c *component[T]) SetValue(value T) {
+	c.value = value
+}
+
+func (c *component[T]) Depth() int {
+	depth := 0
+	for p := c.parent; p != nil; p = p.parent {
+		depth++
+	}
+	return depth
+}
+
+func (c *component[T]) Path() []string {
+	path := []string{c.name}
+	for p := c.parent; p != nil; p = p.parent {
+		path = append(path, p.name)
+	}
+	slices.Reverse(path)
+	return path
+}
 
-type Leaf struct {
-	component
+// Leaf is a Component without children.
+type Leaf[T any] struct {
+	component[T]
 }
 
-func NewLeaf() *Leaf {
-	return &Leaf{}
+func NewLeaf[T any](name string, value T) *Leaf[T] {
+	return &Leaf[T]{component[T]{name: name, value: value}}
 }
 
-func (c *Leaf) Print(pre string) {
-	fmt.Printf("%s-%s\n", pre, c.Name())
+func (c *Leaf[T]) Fprint(w io.Writer, pre string) error {
+	_, err := fmt.Fprintf(w, "%s-%s\n", pre, c.Name())
+	return err
 }
 
-type Composite struct {
-	component
-	childs []Component
+func (c *Leaf[T]) Print(pre string) {
+	c.Fprint(os.Stdout, pre)
 }
 
-func NewComposite() *Composite {
-	return &Composite{
-		childs: make([]Component, 0),
+// Composite is a Component holding an ordered list of children.
+// Like the other containers of this package, a tree is not safe for concurrent use.
+type Composite[T any] struct {
+	component[T]
+	childs []Component[T]
+}
+
+func NewComposite[T any](name string, value T) *Composite[T] {
+	return &Composite[T]{
+		component: component[T]{name: name, value: value},
+		childs:    make([]Component[T], 0),
+	}
+}
+
+// AddChild appends child, moving it from its current parent if it has one.
+func (c *Composite[T]) AddChild(child Component[T]) error {
+	return c.InsertChild(len(c.childs), child)
+}
+
+// InsertChild inserts child at position i, moving it from its current parent if it has one.
+// It returns ErrTreeCycle if child is c or one of its ancestors.
+func (c *Composite[T]) InsertChild(i int, child Component[T]) error {
+	if i < 0 || i > len(c.childs) {
+		return ErrTreeIndex
+	}
+	for p := c; p != nil; p = p.parent {
+		if Component[T](p) == child {
+			return ErrTreeCycle
+		}
+	}
+
+	if parent := child.Parent(); parent != nil {
+		parent.RemoveChild(child)
 	}
+	i = min(i, len(c.childs))
+	child.setParent(c)
+	c.childs = slices.Insert(c.childs, i, child)
+	return nil
 }
 
-func (c *Composite) AddChild(child Component) {
-	child.SetParent(c)
-	c.childs = append(c.childs, child)
+// RemoveChild detaches child, which becomes a root, and reports whether it was a child of c.
+func (c *Composite[T]) RemoveChild(child Component[T]) bool {
+	i := slices.Index(c.childs, child)
+	if i < 0 {
+		return false
+	}
+	c.childs = slices.Delete(c.childs, i, i+1)
+	child.setParent(nil)
+	return true
 }
 
-func (c *Composite) Print(pre string) {
-	fmt.Printf("%s+%s\n", pre, c.Name())
+// Children returns the direct children of c in order.
+func (c *Composite[T]) Children() []Component[T] {
+	return slices.Clone(c.childs)
+}
+
+// Find returns the first node of the subtree rooted at c, c included, matching predicate in depth-first order.
+func (c *Composite[T]) Find(predicate func(Component[T]) bool) (Component[T], bool) {
+	for node := range c.DFS() {
+		if predicate(node) {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// DFS returns an iterator over the subtree rooted at c in depth-first pre-order, c first.
+func (c *Composite[T]) DFS() iter.Seq[Component[T]] {
+	return func(yield func(Component[T]) bool) {
+		c.Walk(func(node Component[T], _ int) WalkAction {
+			if !yield(node) {
+				return WalkStop
+			}
+			return WalkContinue
+		})
+	}
+}
+
+// BFS returns an iterator over the subtree rooted at c level by level, c first.
+func (c *Composite[T]) BFS() iter.Seq[Component[T]] {
+	return func(yield func(Component[T]) bool) {
+		queue := []Component[T]{c}
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			if !yield(node) {
+				return
+			}
+			if composite, ok := node.(*Composite[T]); ok {
+				queue = append(queue, composite.childs...)
+			}
+		}
+	}
+}
+
+// WalkAction tells Walk how to go on after visiting a node.
+type WalkAction int
+
+const (
+	// WalkContinue visits the children of the node, then the rest of the tree.
+	WalkContinue WalkAction = iota
+	// WalkSkip does not visit the children of the node.
+	WalkSkip
+	// WalkStop ends the walk.
+	WalkStop
+)
+
+// Walk visits the subtree rooted at c in depth-first pre-order, passing every node with its depth
+// relative to c. It returns false if fn stopped the walk.
+func (c *Composite[T]) Walk(fn func(node Component[T], depth int) WalkAction) bool {
+	return walkComponent(c, 0, fn)
+}
+
+func walkComponent[T any](node Component[T], depth int, fn func(Component[T], int) WalkAction) bool {
+	switch fn(node, depth) {
+	case WalkStop:
+		return false
+	case WalkSkip:
+		return true
+	}
+
+	if composite, ok := node.(*Composite[T]); ok {
+		// The children are copied so fn may reorganize the tree while walking.
+		for _, child := range slices.Clone(composite.childs) {
+			if !walkComponent(child, depth+1, fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (c *Composite[T]) Fprint(w io.Writer, pre string) error {
+	if _, err := fmt.Fprintf(w, "%s+%s\n", pre, c.Name()); err != nil {
+		return err
+	}
 	pre += " "
 	for _, comp := range c.childs {
-		comp.Print(pre)
+		if err := comp.Fprint(w, pre); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+func (c *Composite[T]) Print(pre string) {
+	c.Fprint(os.Stdout, pre)
 }
@@ -0,0 +1,117 @@
+package sugar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TreeChangeKind is the kind of a TreeChange.
+type TreeChangeKind int
+
+const (
+	// TreeAdded is a node present only in the new tree.
+	TreeAdded TreeChangeKind = iota
+	// TreeRemoved is a node present only in the old tree.
+	TreeRemoved
+	// TreeModified is a node whose value changed.
+	TreeModified
+)
+
+// TreeChange is a difference between two trees. Path holds the names from the root to the node.
+type TreeChange[T any] struct {
+	Kind TreeChangeKind
+	Path []string
+	Old  T
+	New  T
+}
+
+// String formats the change as "+ a/b", "- a/b" or "~ a/b: old -> new".
+func (c TreeChange[T]) String() string {
+	path := strings.Join(c.Path, "/")
+	switch c.Kind {
+	case TreeAdded:
+		return "+ " + path
+	case TreeRemoved:
+		return "- " + path
+	default:
+		return fmt.Sprintf("~ %s: %v -> %v", path, c.Old, c.New)
+	}
+}
+
+// DiffTrees returns the changes turning the tree rooted at old into the one rooted at new.
+// Children are matched by name, in order when names repeat. A node turning from a Leaf into
+// a Composite, or the reverse, is reported as removed and added.
+func DiffTrees[T comparable](old, new Component[T]) []TreeChange[T] {
+	return DiffTreesFunc(old, new, func(a, b T) bool {
+		return a == b
+	})
+}
+
+// DiffTreesFunc is like DiffTrees, comparing values with equal.
+func DiffTreesFunc[T any](old, new Component[T], equal func(a, b T) bool) []TreeChange[T] {
+	var changes []TreeChange[T]
+	diffComponents(&changes, nil, old, new, equal)
+	return changes
+}
+
+func diffComponents[T any](changes *[]TreeChange[T], parent []string, old, new Component[T], equal func(a, b T) bool) {
+	path := append(parent[:len(parent):len(parent)], new.Name())
+	if old.Name() != new.Name() || isTreeLeaf(old) != isTreeLeaf(new) {
+		reportSubtree(changes, parent, old, TreeRemoved)
+		reportSubtree(changes, parent, new, TreeAdded)
+		return
+	}
+
+	if !equal(old.Value(), new.Value()) {
+		*changes = append(*changes, TreeChange[T]{Kind: TreeModified, Path: path, Old: old.Value(), New: new.Value()})
+	}
+
+	oldComposite, ok := old.(*Composite[T])
+	if !ok {
+		return
+	}
+	newComposite := new.(*Composite[T])
+
+	matched := make([]bool, len(newComposite.childs))
+	for _, oldChild := range oldComposite.childs {
+		found := false
+		for i, newChild := range newComposite.childs {
+			if !matched[i] && newChild.Name() == oldChild.Name() {
+				matched[i], found = true, true
+				diffComponents(changes, path, oldChild, newChild, equal)
+				break
+			}
+		}
+		if !found {
+			reportSubtree(changes, path, oldChild, TreeRemoved)
+		}
+	}
+	for i, newChild := range newComposite.childs {
+		if !matched[i] {
+			reportSubtree(changes, path, newChild, TreeAdded)
+		}
+	}
+}
+
+// reportSubtree reports node and its descendants as added or removed.
+func reportSubtree[T any](changes *[]TreeChange[T], parent []string, node Component[T], kind TreeChangeKind) {
+	path := append(parent[:len(parent):len(parent)], node.Name())
+	change := TreeChange[T]{Kind: kind, Path: path}
+	if kind == TreeAdded {
+		change.New = node.Value()
+	} else {
+		change.Old = node.Value()
+	}
+	*changes = append(*changes, change)
+
+	if composite, ok := node.(*Composite[T]); ok {
+		for _, child := range composite.childs {
+			reportSubtree(changes, path, child, kind)
+		}
+	}
+}
+
+func isTreeLeaf[T any](node Component[T]) bool {
+	_, ok := node.(*Leaf[T])
+	return ok
+}
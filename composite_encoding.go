@@ -0,0 +1,225 @@
+package sugar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTreeSyntax is returned when decoding a malformed tree.
+var ErrTreeSyntax = errors.New("sugar: malformed tree encoding")
+
+// treeNode is the nested form trees are encoded to. Composites without children are told apart
+// from leaves by the Leaf flag.
+type treeNode[T any] struct {
+	Name     string        `json:"name"`
+	Value    T             `json:"value"`
+	Leaf     bool          `json:"leaf,omitempty"`
+	Children []treeNode[T] `json:"children,omitempty"`
+}
+
+func toTreeNode[T any](c Component[T]) treeNode[T] {
+	node := treeNode[T]{Name: c.Name(), Value: c.Value()}
+	composite, ok := c.(*Composite[T])
+	if !ok {
+		node.Leaf = true
+		return node
+	}
+	for _, child := range composite.childs {
+		node.Children = append(node.Children, toTreeNode(child))
+	}
+	return node
+}
+
+// build returns the Component described by n, with its parent links set.
+func (n treeNode[T]) build() (Component[T], error) {
+	if n.Leaf {
+		if len(n.Children) > 0 {
+			return nil, fmt.Errorf("%w: leaf %q has children", ErrTreeSyntax, n.Name)
+		}
+		return NewLeaf(n.Name, n.Value), nil
+	}
+
+	composite := NewComposite(n.Name, n.Value)
+	if err := composite.addTreeNodes(n.Children); err != nil {
+		return nil, err
+	}
+	return composite, nil
+}
+
+func (c *Composite[T]) addTreeNodes(nodes []treeNode[T]) error {
+	for _, n := range nodes {
+		child, err := n.build()
+		if err != nil {
+			return err
+		}
+		c.AddChild(child)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the leaf as {"name": ..., "value": ..., "leaf": true}.
+func (c *Leaf[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toTreeNode[T](c))
+}
+
+// UnmarshalJSON decodes a leaf encoded by MarshalJSON. The parent is left unchanged.
+func (c *Leaf[T]) UnmarshalJSON(data []byte) error {
+	var n treeNode[T]
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	if !n.Leaf {
+		return fmt.Errorf("%w: %q is not a leaf", ErrTreeSyntax, n.Name)
+	}
+	c.name, c.value = n.Name, n.Value
+	return nil
+}
+
+// MarshalJSON encodes the subtree as nested {"name": ..., "value": ..., "children": [...]} objects.
+func (c *Composite[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toTreeNode[T](c))
+}
+
+// UnmarshalJSON replaces the children of c with the decoded subtree. The parent of c is left unchanged.
+func (c *Composite[T]) UnmarshalJSON(data []byte) error {
+	var n treeNode[T]
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	if n.Leaf {
+		return fmt.Errorf("%w: %q is not a composite", ErrTreeSyntax, n.Name)
+	}
+
+	for _, child := range c.Children() {
+		c.RemoveChild(child)
+	}
+	c.name, c.value = n.Name, n.Value
+	return c.addTreeNodes(n.Children)
+}
+
+// UnmarshalTree decodes a tree encoded as JSON by MarshalJSON, whether its root is a Leaf or a Composite.
+func UnmarshalTree[T any](data []byte) (Component[T], error) {
+	var n treeNode[T]
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	return n.build()
+}
+
+// MarshalTreeYAML encodes the tree rooted at root as a YAML document. Every node is a sequence
+// item with "name" and "value" keys, "leaf: true" for leaves, and a "children" key holding the
+// nested sequence of a composite. Names and values are written as JSON, which YAML reads as flow scalars.
+func MarshalTreeYAML[T any](root Component[T]) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeTreeYAML(&b, toTreeNode(root), ""); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeTreeYAML[T any](b *bytes.Buffer, n treeNode[T], indent string) error {
+	name, err := json.Marshal(n.Name)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(n.Value)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b, "%s- name: %s\n%s  value: %s\n", indent, name, indent, value)
+	if n.Leaf {
+		fmt.Fprintf(b, "%s  leaf: true\n", indent)
+	}
+	if len(n.Children) > 0 {
+		fmt.Fprintf(b, "%s  children:\n", indent)
+		for _, child := range n.Children {
+			if err := writeTreeYAML(b, child, indent+"    "); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type treeYAMLLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// UnmarshalTreeYAML decodes a tree written by MarshalTreeYAML.
+func UnmarshalTreeYAML[T any](data []byte) (Component[T], error) {
+	var lines []treeYAMLLine
+	for i, line := range strings.Split(string(data), "\n") {
+		text := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lines = append(lines, treeYAMLLine{number: i + 1, indent: len(line) - len(text), text: strings.TrimRight(text, " \r")})
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: empty document", ErrTreeSyntax)
+	}
+
+	n, next, err := parseTreeYAML[T](lines, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("%w: line %d: unexpected content after the root", ErrTreeSyntax, lines[next].number)
+	}
+	return n.build()
+}
+
+// parseTreeYAML parses the node starting at lines[pos], indented by indent, and returns the
+// position of the line following it.
+func parseTreeYAML[T any](lines []treeYAMLLine, pos, indent int) (treeNode[T], int, error) {
+	var n treeNode[T]
+
+	line := lines[pos]
+	if line.indent != indent || !strings.HasPrefix(line.text, "- ") {
+		return n, pos, fmt.Errorf("%w: line %d: expected a node", ErrTreeSyntax, line.number)
+	}
+	lines[pos].text = line.text[2:]
+	lines[pos].indent += 2
+
+	for pos < len(lines) && lines[pos].indent == indent+2 && !strings.HasPrefix(lines[pos].text, "- ") {
+		line := lines[pos]
+		key, value, ok := strings.Cut(line.text, ":")
+		value = strings.TrimSpace(value)
+		if !ok {
+			return n, pos, fmt.Errorf("%w: line %d: expected a key", ErrTreeSyntax, line.number)
+		}
+
+		var err error
+		switch key {
+		case "name":
+			err = json.Unmarshal([]byte(value), &n.Name)
+		case "value":
+			err = json.Unmarshal([]byte(value), &n.Value)
+		case "leaf":
+			err = json.Unmarshal([]byte(value), &n.Leaf)
+		case "children":
+			pos++
+			for pos < len(lines) && lines[pos].indent == indent+4 {
+				var child treeNode[T]
+				if child, pos, err = parseTreeYAML[T](lines, pos, indent+4); err != nil {
+					return n, pos, err
+				}
+				n.Children = append(n.Children, child)
+			}
+			continue
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return n, pos, fmt.Errorf("%w: line %d: %v", ErrTreeSyntax, line.number, err)
+		}
+		pos++
+	}
+	return n, pos, nil
+}
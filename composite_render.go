@@ -0,0 +1,69 @@
+package sugar
+
+import (
+	"fmt"
+	"io"
+)
+
+// TreeStyle is the set of prefixes a TreeRenderer draws the branches with.
+type TreeStyle struct {
+	Branch   string // before a child followed by siblings
+	Last     string // before the last child
+	Vertical string // below a child followed by siblings
+	Space    string // below the last child
+}
+
+var (
+	// ASCIITree draws branches with plain ASCII characters.
+	ASCIITree = TreeStyle{Branch: "|-- ", Last: "`-- ", Vertical: "|   ", Space: "    "}
+	// UnicodeTree draws branches with box-drawing characters, like the tree command.
+	UnicodeTree = TreeStyle{Branch: "├── ", Last: "└── ", Vertical: "│   ", Space: "    "}
+)
+
+// TreeRenderer writes a tree of Components, one node per line.
+type TreeRenderer[T any] struct {
+	Style TreeStyle
+	// Label formats a node. Defaults to its name.
+	Label func(node Component[T]) string
+}
+
+// RenderTree writes the tree rooted at root to w in style, labelling nodes with their name.
+func RenderTree[T any](w io.Writer, root Component[T], style TreeStyle) error {
+	return TreeRenderer[T]{Style: style}.Render(w, root)
+}
+
+// Render writes the tree rooted at root to w.
+func (r TreeRenderer[T]) Render(w io.Writer, root Component[T]) error {
+	if _, err := fmt.Fprintln(w, r.label(root)); err != nil {
+		return err
+	}
+	return r.renderChildren(w, root, "")
+}
+
+func (r TreeRenderer[T]) renderChildren(w io.Writer, node Component[T], prefix string) error {
+	composite, ok := node.(*Composite[T])
+	if !ok {
+		return nil
+	}
+
+	for i, child := range composite.childs {
+		branch, below := r.Style.Branch, r.Style.Vertical
+		if i == len(composite.childs)-1 {
+			branch, below = r.Style.Last, r.Style.Space
+		}
+		if _, err := fmt.Fprintf(w, "%s%s%s\n", prefix, branch, r.label(child)); err != nil {
+			return err
+		}
+		if err := r.renderChildren(w, child, prefix+below); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r TreeRenderer[T]) label(node Component[T]) string {
+	if r.Label != nil {
+		return r.Label(node)
+	}
+	return node.Name()
+}
@@ -0,0 +1,80 @@
+package sugar
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func sampleTree() *Composite[int] {
+	root := NewComposite("root", 0)
+	src := NewComposite("src", 1)
+	root.AddChild(src)
+	src.AddChild(NewLeaf("main.go", 10))
+	src.AddChild(NewComposite("empty", 2))
+	root.AddChild(NewLeaf("go.mod", 3))
+	return root
+}
+
+func TestRenderTree(t *testing.T) {
+	var b bytes.Buffer
+	if err := RenderTree[int](&b, sampleTree(), UnicodeTree); err != nil {
+		t.Fatal(err)
+	}
+	want := "root\n├── src\n│   ├── main.go\n│   └── empty\n└── go.mod\n"
+	if b.String() != want {
+		t.Fatalf("unexpected rendering:\n%s", b.String())
+	}
+}
+
+func TestTreeEncoding(t *testing.T) {
+	root := sampleTree()
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalTree[int](data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := DiffTrees[int](root, decoded); len(changes) != 0 {
+		t.Fatalf("unexpected JSON round trip changes %v", changes)
+	}
+
+	yaml, err := MarshalTreeYAML[int](root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err = UnmarshalTreeYAML[int](yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := DiffTrees[int](root, decoded); len(changes) != 0 {
+		t.Fatalf("unexpected YAML round trip changes %v:\n%s", changes, yaml)
+	}
+
+	main, _ := decoded.(*Composite[int]).Find(func(c Component[int]) bool { return c.Name() == "main.go" })
+	if _, ok := main.(*Leaf[int]); !ok || main.Parent().Name() != "src" || main.Parent().Parent() != decoded {
+		t.Fatalf("expected parent links to be restored, got %v", main.Path())
+	}
+}
+
+func TestDiffTrees(t *testing.T) {
+	old, new := sampleTree(), sampleTree()
+	src, _ := new.Find(func(c Component[int]) bool { return c.Name() == "src" })
+	src.SetValue(5)
+	src.(*Composite[int]).AddChild(NewLeaf("util.go", 11))
+	gomod, _ := new.Find(func(c Component[int]) bool { return c.Name() == "go.mod" })
+	new.RemoveChild(gomod)
+
+	got := Map(DiffTrees[int](old, new), func(c TreeChange[int], _ int) string {
+		return c.String()
+	})
+	want := []string{"~ root/src: 1 -> 5", "+ root/src/util.go", "- root/go.mod"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected diff %s", strings.Join(got, ", "))
+	}
+}
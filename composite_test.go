@@ -1,17 +1,20 @@
-//go:build ignore
-// +build ignore
-
 package sugar
 
+import (
+	"iter"
+	"slices"
+	"testing"
+)
+
 func ExampleComposite() {
-	root := NewComponent(CompositeNode, "root")
-	c1 := NewComponent(CompositeNode, "c1")
-	c2 := NewComponent(CompositeNode, "c2")
-	c3 := NewComponent(CompositeNode, "c3")
+	root := NewComposite("root", 0)
+	c1 := NewComposite("c1", 0)
+	c2 := NewComposite("c2", 0)
+	c3 := NewComposite("c3", 0)
 
-	l1 := NewComponent(LeafNode, "l1")
-	l2 := NewComponent(LeafNode, "l2")
-	l3 := NewComponent(LeafNode, "l3")
+	l1 := NewLeaf("l1", 1)
+	l2 := NewLeaf("l2", 2)
+	l3 := NewLeaf("l3", 3)
 
 	root.AddChild(c1)
 	root.AddChild(c2)
@@ -30,3 +33,72 @@ func ExampleComposite() {
 	//   -l2
 	//   -l3
 }
+
+func componentNames[T any](seq iter.Seq[Component[T]]) []string {
+	var result []string
+	for node := range seq {
+		result = append(result, node.Name())
+	}
+	return result
+}
+
+func TestCompositeTraversal(t *testing.T) {
+	root := NewComposite("root", 0)
+	a := NewComposite("a", 0)
+	b := NewComposite("b", 0)
+	root.AddChild(a)
+	root.AddChild(b)
+	a.AddChild(NewLeaf("a1", 1))
+	b.AddChild(NewLeaf("b1", 2))
+
+	if got := componentNames(root.DFS()); !slices.Equal(got, []string{"root", "a", "a1", "b", "b1"}) {
+		t.Fatalf("unexpected DFS %v", got)
+	}
+	if got := componentNames(root.BFS()); !slices.Equal(got, []string{"root", "a", "b", "a1", "b1"}) {
+		t.Fatalf("unexpected BFS %v", got)
+	}
+
+	var visited []string
+	root.Walk(func(node Component[int], depth int) WalkAction {
+		visited = append(visited, node.Name())
+		if node.Name() == "a" {
+			return WalkSkip
+		}
+		if node.Name() == "b" {
+			return WalkStop
+		}
+		return WalkContinue
+	})
+	if !slices.Equal(visited, []string{"root", "a", "b"}) {
+		t.Fatalf("unexpected walk %v", visited)
+	}
+
+	node, ok := root.Find(func(c Component[int]) bool { return c.Value() == 2 })
+	if !ok || node.Depth() != 2 || !slices.Equal(node.Path(), []string{"root", "b", "b1"}) {
+		t.Fatalf("unexpected find result %v", node)
+	}
+}
+
+func TestCompositeMutation(t *testing.T) {
+	root := NewComposite("root", "")
+	a := NewComposite("a", "")
+	b := NewComposite("b", "")
+	leaf := NewLeaf("leaf", "x")
+	root.AddChild(a)
+	root.AddChild(b)
+	a.AddChild(leaf)
+
+	if err := b.AddChild(leaf); err != nil || leaf.Parent() != b || len(a.Children()) != 0 {
+		t.Fatalf("expected leaf to move to b, got %v", err)
+	}
+	if err := a.AddChild(root); err != ErrTreeCycle {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if err := root.InsertChild(0, b); err != nil || root.Children()[0] != Component[string](b) {
+		t.Fatalf("expected b to move first, got %v", err)
+	}
+
+	if !b.RemoveChild(leaf) || leaf.Parent() != nil || b.RemoveChild(leaf) {
+		t.Fatal("expected leaf to be removed once")
+	}
+}
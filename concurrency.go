@@ -1,6 +1,9 @@
 package sugar
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 type synchronize struct {
 	locker sync.Locker
@@ -32,44 +35,88 @@ func Async[A any](f func() A) chan A {
 	return ch
 }
 
-// FanIn ...
-func FanIn(in ...chan any) <-chan any {
-	out := make(chan any)
-	for i := range in {
-		tmp := in[i]
+// FanIn forwards every value received from the in channels to a single output channel.
+// The output is closed once all inputs are closed or ctx is done.
+func FanIn[T any](ctx context.Context, in ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	var wg sync.WaitGroup
+	wg.Add(len(in))
+	for _, ch := range in {
 		go func() {
-			out <- tmp
+			defer wg.Done()
+			for v := range OrDone(ctx, ch) {
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
 		}()
 	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
 	return out
 }
 
-func FanOut(ch <-chan any, n int) []chan any {
-	cs := make([]chan any, 0, n)
-	for i := 0; i < n; i++ {
-		cs = append(cs, make(chan any))
+// Merge is an alias of FanIn.
+func Merge[T any](ctx context.Context, in ...<-chan T) <-chan T {
+	return FanIn(ctx, in...)
+}
+
+// FanOutMode selects how FanOut distributes values.
+type FanOutMode int
+
+const (
+	// RoundRobin sends every value to exactly one output, cycling through them in order.
+	RoundRobin FanOutMode = iota
+	// Broadcast sends every value to all outputs. A slow reader slows down all of them.
+	Broadcast
+)
+
+// FanOut distributes the values of in over n output channels according to mode.
+// The outputs are closed once in is closed or ctx is done. An n below 1 is treated as 1.
+func FanOut[T any](ctx context.Context, in <-chan T, n int, mode FanOutMode) []<-chan T {
+	n = max(n, 1)
+	cs := make([]chan T, n)
+	outs := make([]<-chan T, n)
+	for i := range cs {
+		cs[i] = make(chan T)
+		outs[i] = cs[i]
 	}
-	distributeToChannels := func(ch <-chan any, cs []chan any) {
-		defer func(cs []chan any) {
+
+	go func() {
+		defer func() {
 			for _, c := range cs {
 				close(c)
 			}
-		}(cs)
+		}()
 
-		for {
-			for _, c := range cs {
+		next := 0
+		for v := range OrDone(ctx, in) {
+			if mode == RoundRobin {
 				select {
-				case val, ok := <-ch:
-					if !ok {
-						return
-					}
+				case cs[next] <- v:
+				case <-ctx.Done():
+					return
+				}
+				next = (next + 1) % n
+				continue
+			}
 
-					c <- val
+			for _, c := range cs {
+				select {
+				case c <- v:
+				case <-ctx.Done():
+					return
 				}
 			}
 		}
-	}
-	go distributeToChannels(ch, cs)
+	}()
 
-	return cs
+	return outs
 }
@@ -0,0 +1,407 @@
+package sugar
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrGroupClosed is returned when joining a closed ConsumerGroup.
+	ErrGroupClosed = errors.New("sugar: consumer group has been closed")
+	// ErrDeliverySettled is returned by Delivery.Ack and Delivery.Nack when the delivery was already
+	// acknowledged, rejected, or its ack deadline passed and it was handed out again.
+	ErrDeliverySettled = errors.New("sugar: delivery already settled")
+)
+
+// GroupOption configures a ConsumerGroup.
+type GroupOption func(*groupConfig)
+
+type groupConfig struct {
+	ackDeadline   time.Duration
+	maxDeliveries int
+	buffer        int
+	subOpts       []SubscriptionOption
+	clock         Clock
+}
+
+// GroupAckDeadline sets how long a member has to Ack a delivery before it is redelivered. Defaults to 30s.
+func GroupAckDeadline(d time.Duration) GroupOption {
+	return func(c *groupConfig) {
+		c.ackDeadline = d
+	}
+}
+
+// GroupMaxDeliveries sets how many times a message is delivered before it is given up on and sent
+// to the dead-letter topic set with ConsumerGroup.SetDeadLetter, if any. Defaults to 0, meaning
+// messages are redelivered until acknowledged.
+func GroupMaxDeliveries(n int) GroupOption {
+	return func(c *groupConfig) {
+		c.maxDeliveries = n
+	}
+}
+
+// GroupBuffer sets how many messages the group takes from its topic ahead of its members.
+// Once they are buffered, the topic blocks its publishers until a member takes one. Defaults to 16.
+func GroupBuffer(n int) GroupOption {
+	return func(c *groupConfig) {
+		c.buffer = n
+	}
+}
+
+// GroupSubscriptionOptions sets the options of the group's subscription to its topic,
+// e.g. SubscriptionFromOffset to resume after a restart.
+func GroupSubscriptionOptions(opts ...SubscriptionOption) GroupOption {
+	return func(c *groupConfig) {
+		c.subOpts = opts
+	}
+}
+
+// GroupClock sets the Clock used for ack deadlines. Defaults to SystemClock.
+func GroupClock(clock Clock) GroupOption {
+	return func(c *groupConfig) {
+		c.clock = clock
+	}
+}
+
+// DeadLetter is published on the dead-letter topic of a ConsumerGroup for a message it gave up on.
+// Message.Topic holds the subject the message was originally published on.
+type DeadLetter[T any] struct {
+	Message Message[T]
+	Group   string
+	// Attempts is the number of times the message was delivered.
+	Attempts int
+}
+
+// GroupStats is a snapshot of a ConsumerGroup's counters.
+type GroupStats struct {
+	Members  int
+	InFlight int
+	// Redelivered counts deliveries handed out again after a Nack or a missed ack deadline.
+	Redelivered  uint64
+	DeadLettered uint64
+}
+
+// Delivery is a message handed to a GroupMember. It must be settled with Ack or Nack.
+type Delivery[T any] struct {
+	Message Message[T]
+	// Attempt is 1 on the first delivery of the message and grows with every redelivery.
+	Attempt int
+
+	group *ConsumerGroup[T]
+}
+
+// Ack marks the message as processed.
+func (d *Delivery[T]) Ack() error {
+	if !d.group.settle(d) {
+		return ErrDeliverySettled
+	}
+	return nil
+}
+
+// Nack rejects the message so it is redelivered right away, or dead-lettered once it reached
+// the maximum number of deliveries.
+func (d *Delivery[T]) Nack() error {
+	if !d.group.settle(d) {
+		return ErrDeliverySettled
+	}
+	d.group.retry(d)
+	return nil
+}
+
+// ConsumerGroup gives a Topic at-least-once semantics: every message is delivered to exactly one
+// member of the group at a time and redelivered, possibly to another member, until it is
+// acknowledged. A group with a single member is an acknowledged subscription.
+// It is safe for concurrent use.
+type ConsumerGroup[T any] struct {
+	name   string
+	config groupConfig
+	sub    *Subscription[T]
+
+	ready     chan *Delivery[T]
+	redeliver chan *Delivery[T]
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu         sync.Mutex
+	members    map[*GroupMember[T]]struct{}
+	inflight   map[*Delivery[T]]Timer // nil until the member received the delivery
+	deadLetter *Topic[DeadLetter[T]]
+
+	redelivered  atomic.Uint64
+	deadLettered atomic.Uint64
+}
+
+// NewConsumerGroup subscribes a new group to topic.
+func NewConsumerGroup[T any](topic *Topic[T], name string, opts ...GroupOption) (*ConsumerGroup[T], error) {
+	config := groupConfig{
+		ackDeadline: 30 * time.Second,
+		buffer:      16,
+		clock:       SystemClock(),
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	g := &ConsumerGroup[T]{
+		name:      name,
+		config:    config,
+		ready:     make(chan *Delivery[T]),
+		redeliver: make(chan *Delivery[T]),
+		done:      make(chan struct{}),
+		members:   make(map[*GroupMember[T]]struct{}),
+		inflight:  make(map[*Delivery[T]]Timer),
+	}
+
+	// The subscription blocks the publisher rather than losing messages once dispatch stops
+	// reading from it, see GroupBuffer.
+	sub, err := topic.Subscribe(0, name, append(append([]SubscriptionOption(nil), config.subOpts...), SubscriptionPolicy(SlowConsumerBlock))...)
+	if err != nil {
+		return nil, err
+	}
+	g.sub = sub
+
+	go g.dispatch()
+	return g, nil
+}
+
+// Name returns the name the group was created with.
+func (g *ConsumerGroup[T]) Name() string {
+	return g.name
+}
+
+// SetDeadLetter publishes the messages exceeding GroupMaxDeliveries on topic, wrapped in a
+// DeadLetter. A nil topic drops them, which is the default.
+func (g *ConsumerGroup[T]) SetDeadLetter(topic *Topic[DeadLetter[T]]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.deadLetter = topic
+}
+
+// dispatch queues the messages received from the topic and the redeliveries, and hands them
+// to whichever member is ready first. It stops reading from the topic while the queue is full;
+// redeliveries are always queued since they come from deliveries already taken.
+func (g *ConsumerGroup[T]) dispatch() {
+	var queue []*Delivery[T]
+	inbox := g.sub.Inbox
+
+	for {
+		var ready chan *Delivery[T]
+		var next *Delivery[T]
+		if len(queue) > 0 {
+			ready = g.ready
+			next = queue[0]
+		}
+		var in <-chan Message[T]
+		if len(queue) < max(g.config.buffer, 1) {
+			in = inbox
+		}
+
+		select {
+		case msg, ok := <-in:
+			if !ok {
+				inbox = nil
+				continue
+			}
+			queue = append(queue, &Delivery[T]{Message: msg, Attempt: 1, group: g})
+		case d := <-g.redeliver:
+			queue = append(queue, d)
+		case ready <- next:
+			queue[0] = nil
+			queue = queue[1:]
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// track marks d as in flight while it is handed to a member. Its ack deadline starts with arm.
+func (g *ConsumerGroup[T]) track(d *Delivery[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inflight[d] = nil
+}
+
+// arm starts the ack deadline of d once a member received it, unless it was settled meanwhile.
+func (g *ConsumerGroup[T]) arm(d *Delivery[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.inflight[d]; !ok {
+		return
+	}
+	g.inflight[d] = g.config.clock.AfterFunc(g.config.ackDeadline, func() {
+		if g.settle(d) {
+			g.retry(d)
+		}
+	})
+}
+
+// settle stops tracking d and reports whether it was still in flight.
+func (g *ConsumerGroup[T]) settle(d *Delivery[T]) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	timer, ok := g.inflight[d]
+	if !ok {
+		return false
+	}
+	if timer != nil {
+		timer.Stop()
+	}
+	delete(g.inflight, d)
+	return true
+}
+
+// retry redelivers the message of a settled delivery or dead-letters it.
+func (g *ConsumerGroup[T]) retry(d *Delivery[T]) {
+	if g.config.maxDeliveries > 0 && d.Attempt >= g.config.maxDeliveries {
+		g.deadLettered.Add(1)
+		g.mu.Lock()
+		deadLetter := g.deadLetter
+		g.mu.Unlock()
+		if deadLetter != nil {
+			deadLetter.Publish(DeadLetter[T]{Message: d.Message, Group: g.name, Attempts: d.Attempt})
+		}
+		return
+	}
+
+	g.redelivered.Add(1)
+	g.requeue(&Delivery[T]{Message: d.Message, Attempt: d.Attempt + 1, group: g})
+}
+
+func (g *ConsumerGroup[T]) requeue(d *Delivery[T]) {
+	select {
+	case g.redeliver <- d:
+	case <-g.done:
+	}
+}
+
+// Join adds a member receiving its share of the messages on Deliveries.
+func (g *ConsumerGroup[T]) Join(uid uint64, name string) (*GroupMember[T], error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	select {
+	case <-g.done:
+		return nil, ErrGroupClosed
+	default:
+	}
+
+	deliveries := make(chan *Delivery[T])
+	m := &GroupMember[T]{
+		Session: Session{
+			User: User{
+				ID:   uid,
+				Name: name,
+			},
+			Timestamp: time.Now(),
+		},
+		Deliveries: deliveries,
+		group:      g,
+		deliveries: deliveries,
+		done:       make(chan struct{}),
+	}
+	g.members[m] = struct{}{}
+
+	go m.run()
+	return m, nil
+}
+
+// Members returns the sessions of the current members.
+func (g *ConsumerGroup[T]) Members() []Session {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return Map(Keys(g.members), func(m *GroupMember[T], _ int) Session {
+		return m.Session
+	})
+}
+
+// Stats returns the current counters of the group.
+func (g *ConsumerGroup[T]) Stats() GroupStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return GroupStats{
+		Members:      len(g.members),
+		InFlight:     len(g.inflight),
+		Redelivered:  g.redelivered.Load(),
+		DeadLettered: g.deadLettered.Load(),
+	}
+}
+
+// Close unsubscribes the group from its topic and ends every member.
+// Messages not yet acknowledged are dropped.
+func (g *ConsumerGroup[T]) Close() error {
+	err := ErrGroupClosed
+	g.closeOnce.Do(func() {
+		err = nil
+		g.sub.Unsubscribe()
+
+		g.mu.Lock()
+		close(g.done)
+		for _, timer := range g.inflight {
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+		clear(g.inflight)
+		members := Keys(g.members)
+		g.mu.Unlock()
+
+		for _, m := range members {
+			m.Leave()
+		}
+	})
+	return err
+}
+
+// GroupMember is a member's handle on a ConsumerGroup.
+type GroupMember[T any] struct {
+	Session Session
+	// Deliveries receives the messages assigned to the member. It is closed when the member leaves.
+	Deliveries <-chan *Delivery[T]
+
+	group      *ConsumerGroup[T]
+	deliveries chan *Delivery[T]
+	done       chan struct{}
+	leaveOnce  sync.Once
+}
+
+// run takes one delivery at a time from the group and waits for the member to receive it.
+func (m *GroupMember[T]) run() {
+	defer close(m.deliveries)
+
+	for {
+		select {
+		case d := <-m.group.ready:
+			m.group.track(d)
+			select {
+			case m.deliveries <- d:
+				m.group.arm(d)
+			case <-m.done:
+				// The member never saw the delivery, so it does not count as an attempt.
+				if m.group.settle(d) {
+					m.group.requeue(d)
+				}
+				return
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// Leave removes the member from the group and closes Deliveries. The deliveries it has not
+// acknowledged are redelivered to the other members once their ack deadline passes.
+func (m *GroupMember[T]) Leave() {
+	m.leaveOnce.Do(func() {
+		m.group.mu.Lock()
+		delete(m.group.members, m)
+		m.group.mu.Unlock()
+
+		close(m.done)
+	})
+}
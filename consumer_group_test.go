@@ -0,0 +1,163 @@
+package sugar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsumerGroupRedelivery(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	topic := NewTopic[string]("jobs")
+	dead := NewTopic[DeadLetter[string]]("jobs.dead")
+	deadSub, _ := dead.Subscribe(1, "dlq")
+
+	group, err := NewConsumerGroup(topic, "workers",
+		GroupAckDeadline(time.Second),
+		GroupMaxDeliveries(3),
+		GroupClock(clock),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer group.Close()
+	group.SetDeadLetter(dead)
+
+	member, _ := group.Join(1, "w1")
+	topic.Publish("job")
+
+	d := <-member.Deliveries
+	if d.Attempt != 1 {
+		t.Fatalf("unexpected first attempt %d", d.Attempt)
+	}
+
+	clock.Advance(time.Second)
+	if err := d.Ack(); err != ErrDeliverySettled {
+		t.Fatalf("expected expired delivery, got %v", err)
+	}
+
+	d = <-member.Deliveries
+	if d.Attempt != 2 {
+		t.Fatalf("expected redelivery, got attempt %d", d.Attempt)
+	}
+	d.Nack()
+
+	d = <-member.Deliveries
+	if d.Attempt != 3 {
+		t.Fatalf("expected third attempt, got %d", d.Attempt)
+	}
+	d.Nack()
+
+	if msg := <-deadSub.Inbox; msg.Payload.Message.Payload != "job" || msg.Payload.Message.Topic != "jobs" || msg.Payload.Attempts != 3 {
+		t.Fatalf("unexpected dead letter %+v", msg)
+	}
+	if stats := group.Stats(); stats.DeadLettered != 1 || stats.Redelivered != 2 || stats.InFlight != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestConsumerGroupSlowMember(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	topic := NewTopic[string]("jobs")
+	dead := NewTopic[DeadLetter[string]]("jobs.dead")
+	deadSub, _ := dead.Subscribe(1, "dlq")
+
+	group, _ := NewConsumerGroup(topic, "workers", GroupAckDeadline(time.Second), GroupMaxDeliveries(1), GroupClock(clock))
+	defer group.Close()
+	group.SetDeadLetter(dead)
+
+	member, _ := group.Join(1, "slow")
+	topic.Publish("A")
+	<-member.Deliveries
+	topic.Publish("B")
+
+	// Wait for B to be waiting on the member, which is still busy with A.
+	for deadline := time.Now().Add(time.Second); group.Stats().InFlight < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	clock.Advance(time.Second)
+
+	if msg := <-deadSub.Inbox; msg.Payload.Message.Payload != "A" {
+		t.Fatalf("unexpected dead letter %+v", msg)
+	}
+	d := <-member.Deliveries
+	if d.Message.Payload != "B" || d.Attempt != 1 {
+		t.Fatalf("unexpected delivery %+v", d)
+	}
+	if err := d.Ack(); err != nil {
+		t.Fatalf("expected B to be acknowledged, got %v", err)
+	}
+	if stats := group.Stats(); stats.DeadLettered != 1 {
+		t.Fatalf("expected only A to be dead-lettered, got %+v", stats)
+	}
+}
+
+func TestConsumerGroupMembers(t *testing.T) {
+	topic := NewTopic[int]("jobs")
+	group, _ := NewConsumerGroup(topic, "workers")
+	defer group.Close()
+
+	a, _ := group.Join(1, "a")
+	b, _ := group.Join(2, "b")
+
+	const n = 20
+	go func() {
+		for i := 0; i < n; i++ {
+			topic.Publish(i)
+		}
+	}()
+
+	seen := make(map[int]bool)
+	for len(seen) < n {
+		var d *Delivery[int]
+		select {
+		case d = <-a.Deliveries:
+		case d = <-b.Deliveries:
+		}
+		if seen[d.Message.Payload] {
+			t.Fatalf("message %d delivered twice", d.Message.Payload)
+		}
+		seen[d.Message.Payload] = true
+		if err := d.Ack(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b.Leave()
+	if _, ok := <-b.Deliveries; ok {
+		t.Fatal("expected deliveries to be closed")
+	}
+	if len(group.Members()) != 1 {
+		t.Fatalf("unexpected members %v", group.Members())
+	}
+}
+
+func TestConsumerGroupBackPressure(t *testing.T) {
+	topic := NewTopic[int]("jobs")
+	group, _ := NewConsumerGroup(topic, "workers", GroupBuffer(1), GroupSubscriptionOptions(SubscriptionBuffer(1)))
+	defer group.Close()
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < 10; i++ {
+			topic.Publish(i)
+		}
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("expected a group without members to block the publisher")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	member, _ := group.Join(1, "w")
+	for i := 0; i < 10; i++ {
+		d := <-member.Deliveries
+		if d.Message.Payload != i {
+			t.Fatalf("expected %d, got %d", i, d.Message.Payload)
+		}
+		d.Ack()
+	}
+	<-published
+}
@@ -1,6 +1,133 @@
 package sugar
 
-type Builder interface {
-	Build(m any) Builder
-	CompletedCheck() bool
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MissingFieldsError is returned by Builder.Build when required fields were never set.
+type MissingFieldsError struct {
+	Fields []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	return fmt.Sprintf("sugar: missing required fields: %s", strings.Join(e.Fields, ", "))
+}
+
+// BuilderStep sets the field named Field of a T under construction.
+type BuilderStep[T any] struct {
+	Field string
+	Apply func(*T)
+}
+
+// BuilderField is a typed setter for one field of T, turning values into BuilderSteps.
+type BuilderField[T, V any] struct {
+	name string
+	set  func(*T, V)
+}
+
+// NewBuilderField returns a BuilderField named name, which is what Builder.Require refers to.
+func NewBuilderField[T, V any](name string, set func(*T, V)) BuilderField[T, V] {
+	return BuilderField[T, V]{name: name, set: set}
+}
+
+// Name returns the name of the field.
+func (f BuilderField[T, V]) Name() string {
+	return f.name
+}
+
+// Set returns the step setting the field to v.
+func (f BuilderField[T, V]) Set(v V) BuilderStep[T] {
+	return BuilderStep[T]{
+		Field: f.name,
+		Apply: func(t *T) {
+			f.set(t, v)
+		},
+	}
+}
+
+// Builder assembles a T from steps, checking required fields and running validations.
+// A Builder is immutable: every method returns a new Builder and leaves the receiver untouched,
+// so a partially configured Builder can serve as a template for several values.
+type Builder[T any] struct {
+	defaults   []BuilderStep[T]
+	steps      []BuilderStep[T]
+	required   []string
+	validators []func(T) error
+}
+
+// NewBuilder returns a Builder without steps.
+func NewBuilder[T any]() Builder[T] {
+	return Builder[T]{}
+}
+
+// With returns a Builder applying steps after the current ones.
+func (b Builder[T]) With(steps ...BuilderStep[T]) Builder[T] {
+	b.steps = append(slices.Clip(b.steps), steps...)
+	return b
+}
+
+// Set returns a Builder applying apply, registered as setting field.
+func (b Builder[T]) Set(field string, apply func(*T)) Builder[T] {
+	return b.With(BuilderStep[T]{Field: field, Apply: apply})
+}
+
+// Default returns a Builder applying steps before any other step. A default satisfies the requirement of its field.
+func (b Builder[T]) Default(steps ...BuilderStep[T]) Builder[T] {
+	b.defaults = append(slices.Clip(b.defaults), steps...)
+	return b
+}
+
+// Require returns a Builder failing to Build unless every field in fields is set by a step or a default.
+func (b Builder[T]) Require(fields ...string) Builder[T] {
+	b.required = append(slices.Clip(b.required), fields...)
+	return b
+}
+
+// Validate returns a Builder running validators on the value once built.
+func (b Builder[T]) Validate(validators ...func(T) error) Builder[T] {
+	b.validators = append(slices.Clip(b.validators), validators...)
+	return b
+}
+
+// Missing returns the required fields no step or default sets, in the order they were required.
+func (b Builder[T]) Missing() []string {
+	set := make(map[string]bool)
+	for _, step := range slices.Concat(b.defaults, b.steps) {
+		set[step.Field] = true
+	}
+
+	var missing []string
+	for _, field := range b.required {
+		if !set[field] && !slices.Contains(missing, field) {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// Build applies the defaults then the steps to a zero T. It returns a *MissingFieldsError naming
+// every required field left unset, or else the errors of all failing validators joined.
+func (b Builder[T]) Build() (T, error) {
+	var zero, v T
+	if missing := b.Missing(); len(missing) > 0 {
+		return zero, &MissingFieldsError{Fields: missing}
+	}
+
+	for _, step := range slices.Concat(b.defaults, b.steps) {
+		step.Apply(&v)
+	}
+
+	var errs []error
+	for _, validate := range b.validators {
+		if err := validate(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return zero, err
+	}
+	return v, nil
 }
@@ -1,33 +1,51 @@
-//go:build ignore
-// +build ignore
-
 package sugar
 
 import (
-	"fmt"
+	"errors"
+	"slices"
 	"testing"
 )
 
-type BuilderImplCase struct {
-	M1 any
+type server struct {
+	Host string
+	Port int
+	TLS  bool
 }
 
-func (b *BuilderImplCase) Build(m any) Builder {
-	b.M1 = m
-	return b
-}
+var (
+	serverHost = NewBuilderField("Host", func(s *server, host string) { s.Host = host })
+	serverPort = NewBuilderField("Port", func(s *server, port int) { s.Port = port })
+	serverTLS  = NewBuilderField("TLS", func(s *server, tls bool) { s.TLS = tls })
+)
 
-func (b *BuilderImplCase) CompletedCheck() bool {
-	if !IsNil1(b.M1) {
-		return true
+func TestBuilder(t *testing.T) {
+	template := NewBuilder[server]().
+		Default(serverPort.Set(80)).
+		Require(serverHost.Name(), serverPort.Name(), serverTLS.Name()).
+		Validate(func(s server) error {
+			if s.TLS && s.Port == 80 {
+				return errors.New("TLS on port 80")
+			}
+			return nil
+		})
+
+	_, err := template.Build()
+	var missing *MissingFieldsError
+	if !errors.As(err, &missing) || !slices.Equal(missing.Fields, []string{"Host", "TLS"}) {
+		t.Fatalf("expected missing Host and TLS, got %v", err)
+	}
+
+	secure := template.With(serverHost.Set("example.com"), serverTLS.Set(true))
+	if _, err := secure.Build(); err == nil || err.Error() != "TLS on port 80" {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+
+	s, err := secure.With(serverPort.Set(443)).Build()
+	if err != nil || s != (server{Host: "example.com", Port: 443, TLS: true}) {
+		t.Fatalf("unexpected server %+v: %v", s, err)
 	}
-	return false
-}
 
-func TestBuilderImplCase(t *testing.T) {
-	v := &BuilderImplCase{}
-	completedCheck := v.Build("m").CompletedCheck()
-	if completedCheck {
-		fmt.Println("complete!")
+	if _, err := template.Build(); !errors.As(err, &missing) {
+		t.Fatal("expected the template to be left untouched")
 	}
 }
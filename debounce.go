@@ -0,0 +1,367 @@
+package sugar
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Edge selects on which side of a debounce or throttle window the callback is invoked.
+type Edge int
+
+const (
+	// TrailingEdge invokes the callback once calls have stopped for the wait duration.
+	TrailingEdge Edge = iota
+	// LeadingEdge invokes the callback on the first call of a burst and ignores the rest.
+	LeadingEdge
+	// BothEdges invokes the callback on the first call of a burst and, if more calls
+	// followed, once more at the end of it.
+	BothEdges
+)
+
+// MergeLastArgs keeps the arguments of the most recent call. It is the default merge strategy.
+func MergeLastArgs[T any](_, next []T) []T {
+	return next
+}
+
+// MergeFirstArgs keeps the arguments of the first call of a burst.
+func MergeFirstArgs[T any](pending, _ []T) []T {
+	return pending
+}
+
+// MergeAllArgs concatenates the arguments of every call of a burst.
+func MergeAllArgs[T any](pending, next []T) []T {
+	return append(pending, next...)
+}
+
+// DebounceOption configures a Debouncer.
+type DebounceOption func(*debounceConfig)
+
+type debounceConfig struct {
+	edge    Edge
+	maxWait time.Duration
+	clock   Clock
+	ctx     context.Context
+}
+
+// DebounceEdge sets on which edge the callback is invoked. Defaults to TrailingEdge
+// for NewDebouncer and BothEdges for NewThrottler.
+func DebounceEdge(edge Edge) DebounceOption {
+	return func(c *debounceConfig) {
+		c.edge = edge
+	}
+}
+
+// DebounceMaxWait bounds how long a continuous burst of calls can postpone the callback.
+func DebounceMaxWait(d time.Duration) DebounceOption {
+	return func(c *debounceConfig) {
+		c.maxWait = d
+	}
+}
+
+// DebounceClock sets the Clock driving the timers.
+func DebounceClock(clock Clock) DebounceOption {
+	return func(c *debounceConfig) {
+		c.clock = clock
+	}
+}
+
+// DebounceContext binds the lifetime of the Debouncer to ctx: once ctx is done
+// pending calls are dropped and further calls are ignored, as with Close.
+func DebounceContext(ctx context.Context) DebounceOption {
+	return func(c *debounceConfig) {
+		c.ctx = ctx
+	}
+}
+
+// Debouncer collapses bursts of calls into fewer invocations of a callback.
+// It is safe for concurrent use; the callback runs outside of the Debouncer's lock,
+// on the calling goroutine for leading-edge invocations and on a timer goroutine otherwise.
+type Debouncer[T any] struct {
+	callback func(...T)
+	wait     time.Duration
+	leading  bool
+	trailing bool
+	maxWait  time.Duration
+	merge    func(pending, next []T) []T
+	clock    Clock
+
+	mu             sync.Mutex
+	timer          Timer
+	timerGen       uint64
+	pending        []T
+	hasPending     bool
+	lastCallTime   time.Time
+	lastInvokeTime time.Time
+	closed         bool
+	ctx            context.Context
+	stopContext    func() bool
+}
+
+// NewDebouncer returns a Debouncer invoking callback once calls have stopped for wait.
+func NewDebouncer[T any](callback func(...T), wait time.Duration, opts ...DebounceOption) *Debouncer[T] {
+	return newDebouncer(callback, wait, MergeLastArgs[T], debounceConfig{edge: TrailingEdge}, opts)
+}
+
+// NewMergeDebouncer is NewDebouncer combining the arguments of the calls collapsed into a single
+// invocation with merge, e.g. MergeAllArgs[string], instead of keeping the last ones.
+func NewMergeDebouncer[T any](callback func(...T), wait time.Duration, merge func(pending, next []T) []T, opts ...DebounceOption) *Debouncer[T] {
+	return newDebouncer(callback, wait, merge, debounceConfig{edge: TrailingEdge}, opts)
+}
+
+// NewThrottler returns a Debouncer invoking callback at most once per interval while calls keep coming.
+// It is a debouncer whose maxWait equals its wait.
+func NewThrottler[T any](callback func(...T), interval time.Duration, opts ...DebounceOption) *Debouncer[T] {
+	return newDebouncer(callback, interval, MergeLastArgs[T], debounceConfig{edge: BothEdges, maxWait: interval}, opts)
+}
+
+func newDebouncer[T any](callback func(...T), wait time.Duration, merge func(pending, next []T) []T, config debounceConfig, opts []DebounceOption) *Debouncer[T] {
+	config.clock = SystemClock()
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	maxWait := config.maxWait
+	if maxWait > 0 && maxWait < wait {
+		maxWait = wait
+	}
+
+	d := &Debouncer[T]{
+		callback: callback,
+		wait:     wait,
+		leading:  config.edge == LeadingEdge || config.edge == BothEdges,
+		trailing: config.edge == TrailingEdge || config.edge == BothEdges,
+		maxWait:  maxWait,
+		merge:    merge,
+		clock:    config.clock,
+	}
+
+	if config.ctx != nil {
+		d.ctx = config.ctx
+		d.stopContext = context.AfterFunc(config.ctx, d.Close)
+	}
+
+	return d
+}
+
+// Call records a call with args, invoking the callback now or later according to the configured edges.
+func (d *Debouncer[T]) Call(args ...T) {
+	d.mu.Lock()
+	if d.isClosed() {
+		d.mu.Unlock()
+		return
+	}
+
+	now := d.clock.Now()
+	invoking := d.shouldInvoke(now)
+
+	if d.hasPending {
+		d.pending = d.merge(d.pending, args)
+	} else {
+		d.pending = append([]T(nil), args...)
+		d.hasPending = true
+	}
+	d.lastCallTime = now
+
+	var fire []T
+	var ok bool
+	switch {
+	case invoking && d.timer == nil:
+		// Leading edge of a new burst.
+		d.lastInvokeTime = now
+		d.startTimer(d.wait)
+		if d.leading {
+			fire, ok = d.take(now)
+		}
+	case invoking && d.maxWait > 0:
+		// maxWait elapsed in the middle of a burst.
+		d.startTimer(d.wait)
+		fire, ok = d.take(now)
+	case d.timer == nil:
+		d.startTimer(d.wait)
+	}
+	d.mu.Unlock()
+
+	if ok {
+		d.callback(fire...)
+	}
+}
+
+// Flush immediately performs a pending trailing invocation, if any.
+func (d *Debouncer[T]) Flush() {
+	d.mu.Lock()
+	var fire []T
+	var ok bool
+	if !d.isClosed() && d.timer != nil {
+		d.stopTimer()
+		if d.trailing {
+			fire, ok = d.take(d.clock.Now())
+		}
+		d.pending, d.hasPending = nil, false
+	}
+	d.mu.Unlock()
+
+	if ok {
+		d.callback(fire...)
+	}
+}
+
+// Cancel drops a pending invocation and resets the burst. Later calls start a new burst.
+func (d *Debouncer[T]) Cancel() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reset()
+}
+
+// Close cancels a pending invocation and ignores every later call.
+func (d *Debouncer[T]) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reset()
+	d.closed = true
+	if d.stopContext != nil {
+		d.stopContext()
+	}
+}
+
+// Pending reports whether calls are waiting for a trailing invocation.
+func (d *Debouncer[T]) Pending() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.isClosed() && d.timer != nil && d.hasPending && d.trailing
+}
+
+// isClosed must be called with the lock held. It closes the Debouncer as soon as its context is
+// done, without waiting for the context.AfterFunc callback to run.
+func (d *Debouncer[T]) isClosed() bool {
+	if !d.closed && d.ctx != nil && d.ctx.Err() != nil {
+		d.reset()
+		d.closed = true
+	}
+	return d.closed
+}
+
+// reset must be called with the lock held.
+func (d *Debouncer[T]) reset() {
+	d.stopTimer()
+	d.pending, d.hasPending = nil, false
+	d.lastCallTime = time.Time{}
+	d.lastInvokeTime = time.Time{}
+}
+
+// shouldInvoke must be called with the lock held.
+func (d *Debouncer[T]) shouldInvoke(now time.Time) bool {
+	if d.lastCallTime.IsZero() {
+		return true
+	}
+	sinceCall := now.Sub(d.lastCallTime)
+	sinceInvoke := now.Sub(d.lastInvokeTime)
+	return sinceCall >= d.wait || sinceCall < 0 || (d.maxWait > 0 && sinceInvoke >= d.maxWait)
+}
+
+// remainingWait must be called with the lock held.
+func (d *Debouncer[T]) remainingWait(now time.Time) time.Duration {
+	remaining := d.wait - now.Sub(d.lastCallTime)
+	if d.maxWait > 0 {
+		remaining = min(remaining, d.maxWait-now.Sub(d.lastInvokeTime))
+	}
+	return remaining
+}
+
+// take must be called with the lock held.
+func (d *Debouncer[T]) take(now time.Time) ([]T, bool) {
+	if !d.hasPending {
+		return nil, false
+	}
+	args := d.pending
+	d.pending, d.hasPending = nil, false
+	d.lastInvokeTime = now
+	return args, true
+}
+
+// startTimer must be called with the lock held.
+func (d *Debouncer[T]) startTimer(wait time.Duration) {
+	d.stopTimer()
+	d.timerGen++
+	gen := d.timerGen
+	d.timer = d.clock.AfterFunc(wait, func() {
+		d.timerExpired(gen)
+	})
+}
+
+// stopTimer must be called with the lock held.
+func (d *Debouncer[T]) stopTimer() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+func (d *Debouncer[T]) timerExpired(gen uint64) {
+	d.mu.Lock()
+	if gen != d.timerGen || d.timer == nil || d.isClosed() {
+		d.mu.Unlock()
+		return
+	}
+
+	now := d.clock.Now()
+	if !d.shouldInvoke(now) {
+		d.startTimer(d.remainingWait(now))
+		d.mu.Unlock()
+		return
+	}
+
+	// Trailing edge.
+	d.timer = nil
+	var fire []T
+	var ok bool
+	if d.trailing {
+		fire, ok = d.take(now)
+	}
+	d.pending, d.hasPending = nil, false
+	d.mu.Unlock()
+
+	if ok {
+		d.callback(fire...)
+	}
+}
+
+// Debounce runs callbacks once it has not been triggered for a while.
+//
+// Deprecated: Use Debouncer, which NewDebounce is built on.
+type Debounce struct {
+	d *Debouncer[struct{}]
+}
+
+// NewDebounce returns a trigger delaying fns until it has not been called for duration,
+// and a cancel function that drops a pending run and disables the trigger.
+func NewDebounce(duration time.Duration, fns ...func()) (func(), func()) {
+	d := &Debounce{NewDebouncer(func(...struct{}) {
+		for _, cb := range fns {
+			cb()
+		}
+	}, duration)}
+
+	return func() { d.reset() }, d.cancel
+}
+
+func (d *Debounce) reset() {
+	d.d.Call()
+}
+
+func (d *Debounce) cancel() {
+	d.d.Close()
+}
+
+// DebounceFunc creates a debounced function that delays invoking the callback.
+// It is safe for concurrent use; see NewDebouncer for flushing, cancellation and other edges.
+func DebounceFunc[T any](callback func(...T), delay time.Duration) func(...T) {
+	return NewDebouncer(callback, delay).Call
+}
+
+// Throttle creates a throttled function that only invokes the callback at most once per duration.
+// Calls made in between are dropped. It is safe for concurrent use; see NewThrottler for
+// trailing-edge invocations and RateLimitFunc for queueing calls instead of dropping them.
+func Throttle[T any](callback func(...T), duration time.Duration) func(...T) {
+	return NewThrottler(callback, duration, DebounceEdge(LeadingEdge)).Call
+}
@@ -0,0 +1,124 @@
+package sugar
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDebouncerTrailing(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	var got [][]int
+	d := NewMergeDebouncer(func(args ...int) {
+		got = append(got, args)
+	}, 100*time.Millisecond, MergeAllArgs[int], DebounceClock(clock))
+
+	d.Call(1)
+	clock.Advance(50 * time.Millisecond)
+	d.Call(2)
+	clock.Advance(50 * time.Millisecond)
+	if len(got) != 0 || !d.Pending() {
+		t.Fatalf("expected call to be postponed, got %v", got)
+	}
+
+	clock.Advance(50 * time.Millisecond)
+	if len(got) != 1 || !slices.Equal(got[0], []int{1, 2}) {
+		t.Fatalf("unexpected invocations %v", got)
+	}
+}
+
+func TestDebouncerMaxWaitFlushCancel(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	calls := 0
+	d := NewDebouncer(func(...int) { calls++ }, 100*time.Millisecond, DebounceClock(clock), DebounceMaxWait(250*time.Millisecond))
+
+	for i := 0; i < 6; i++ {
+		d.Call(i)
+		clock.Advance(50 * time.Millisecond)
+	}
+	if calls != 1 {
+		t.Fatalf("expected maxWait to force one invocation, got %d", calls)
+	}
+
+	d.Call(9)
+	d.Flush()
+	if calls != 2 || d.Pending() {
+		t.Fatalf("expected flush to invoke, got %d", calls)
+	}
+
+	d.Call(10)
+	d.Cancel()
+	clock.Advance(time.Second)
+	if calls != 2 {
+		t.Fatalf("expected cancel to drop the call, got %d", calls)
+	}
+}
+
+func TestThrottlerEdges(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	var got []int
+	th := NewThrottler(func(args ...int) {
+		got = append(got, args...)
+	}, 100*time.Millisecond, DebounceClock(clock))
+
+	th.Call(1)
+	th.Call(2)
+	th.Call(3)
+	clock.Advance(100 * time.Millisecond)
+	if !slices.Equal(got, []int{1, 3}) {
+		t.Fatalf("unexpected invocations %v", got)
+	}
+}
+
+func TestThrottleConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	throttled := Throttle(func(...int) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+	}, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			throttled(i)
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected a single call, got %d", calls)
+	}
+}
+
+func TestDebouncerContext(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	d := NewDebouncer(func(...int) { calls++ }, time.Second, DebounceClock(clock), DebounceContext(ctx))
+	d.Call(1)
+	cancel()
+	clock.Advance(time.Second)
+	d.Call(2)
+	d.Flush()
+	clock.Advance(time.Second)
+	if calls != 0 || d.Pending() {
+		t.Fatalf("expected calls to be dropped once the context is done, got %d", calls)
+	}
+
+	closed := NewDebouncer(func(...int) { calls++ }, time.Second, DebounceClock(clock), DebounceContext(context.Background()))
+	closed.Call(1)
+	closed.Close()
+	closed.Call(2)
+	clock.Advance(time.Second)
+	if calls != 0 {
+		t.Fatalf("expected Close to drop calls, got %d", calls)
+	}
+}
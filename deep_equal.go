@@ -0,0 +1,239 @@
+package sugar
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ValueDiff is a value found different by DeepDiff, A and B being its formatted sides.
+// Path locates it from the root "$", e.g. "$.Servers[1].Hosts["eu"]".
+type ValueDiff struct {
+	Path string
+	A    string
+	B    string
+}
+
+func (d ValueDiff) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Path, d.A, d.B)
+}
+
+// DiffReport lists the differences found by DeepDiff.
+type DiffReport []ValueDiff
+
+// String returns one difference per line, handy in test failures.
+func (r DiffReport) String() string {
+	return strings.Join(Map(r, func(d ValueDiff, _ int) string {
+		return d.String()
+	}), "\n")
+}
+
+// DeepEqual reports whether a and b are deeply equal, with the same rules as DeepDiff.
+func DeepEqual[T any](a, b T) bool {
+	return len(DeepDiff(a, b)) == 0
+}
+
+// DeepDiff compares a and b like reflect.DeepEqual and reports every difference with its path.
+// Unexported fields are compared too; struct fields tagged `sugar:"-"` are ignored.
+func DeepDiff[T any](a, b T) DiffReport {
+	d := &deepDiffer{visited: make(map[diffKey]bool)}
+	d.diff("$", reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+	return d.report
+}
+
+// diffKey identifies a pair of already compared references. Like cloneKey, it holds the lengths,
+// capacities and the type, since slices sharing a backing array, or a struct and its first field, have the
+// same address.
+type diffKey struct {
+	a, b       uintptr
+	lenA, lenB int
+	capA, capB int
+	typ        reflect.Type
+}
+
+type deepDiffer struct {
+	visited map[diffKey]bool
+	report  DiffReport
+}
+
+func (d *deepDiffer) add(path string, a, b any) {
+	d.report = append(d.report, ValueDiff{Path: path, A: formatDiffValue(a), B: formatDiffValue(b)})
+}
+
+func formatDiffValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case reflect.Value:
+		if !v.IsValid() {
+			return "<invalid>"
+		}
+		if v.Kind() == reflect.String {
+			return fmt.Sprintf("%q", v.String())
+		}
+		return fmt.Sprintf("%v", v)
+	}
+	return fmt.Sprint(v)
+}
+
+// seen reports whether the pair of references was already compared, which ends cycles.
+func (d *deepDiffer) seen(a, b reflect.Value) bool {
+	key := diffKey{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+	if a.Kind() == reflect.Slice {
+		key.lenA, key.lenB = a.Len(), b.Len()
+		key.capA, key.capB = a.Cap(), b.Cap()
+	}
+	if d.visited[key] {
+		return true
+	}
+	d.visited[key] = true
+	return false
+}
+
+func (d *deepDiffer) diff(path string, a, b reflect.Value) {
+	if a.Type() != b.Type() {
+		d.add(path, "type "+a.Type().String(), "type "+b.Type().String())
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(path, a, b)
+			}
+			return
+		}
+		if a.Pointer() == b.Pointer() || d.seen(a, b) {
+			return
+		}
+		d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(path, a, b)
+			}
+			return
+		}
+		if a.Elem().Type() != b.Elem().Type() {
+			d.add(path, "type "+a.Elem().Type().String(), "type "+b.Elem().Type().String())
+			return
+		}
+		d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Slice:
+		if a.IsNil() != b.IsNil() {
+			d.add(path, nilOrEmpty(a), nilOrEmpty(b))
+			return
+		}
+		if a.Len() > 0 && b.Len() > 0 && a.Pointer() == b.Pointer() && a.Len() == b.Len() {
+			return
+		}
+		if a.Len() > 0 && b.Len() > 0 && d.seen(a, b) {
+			return
+		}
+		d.diffSequence(path, a, b)
+
+	case reflect.Array:
+		d.diffSequence(path, a, b)
+
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() {
+			d.add(path, nilOrEmpty(a), nilOrEmpty(b))
+			return
+		}
+		if a.Pointer() == b.Pointer() || d.seen(a, b) {
+			return
+		}
+		d.diffMap(path, a, b)
+
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if structTag(field) == "-" {
+				continue
+			}
+			d.diff(path+"."+field.Name, a.Field(i), b.Field(i))
+		}
+
+	case reflect.Func:
+		if !a.IsNil() || !b.IsNil() {
+			d.add(path, "func", "func")
+		}
+
+	default:
+		if !scalarEqual(a, b) {
+			d.add(path, a, b)
+		}
+	}
+}
+
+func (d *deepDiffer) diffSequence(path string, a, b reflect.Value) {
+	for i := 0; i < max(a.Len(), b.Len()); i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= a.Len():
+			d.add(elemPath, "<missing>", b.Index(i))
+		case i >= b.Len():
+			d.add(elemPath, a.Index(i), "<missing>")
+		default:
+			d.diff(elemPath, a.Index(i), b.Index(i))
+		}
+	}
+}
+
+func (d *deepDiffer) diffMap(path string, a, b reflect.Value) {
+	keys := a.MapKeys()
+	for _, key := range b.MapKeys() {
+		if !a.MapIndex(key).IsValid() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return formatDiffValue(keys[i]) < formatDiffValue(keys[j])
+	})
+
+	for _, key := range keys {
+		elemPath := fmt.Sprintf("%s[%s]", path, formatDiffValue(key))
+		av, bv := a.MapIndex(key), b.MapIndex(key)
+		switch {
+		case !av.IsValid():
+			d.add(elemPath, "<missing>", bv)
+		case !bv.IsValid():
+			d.add(elemPath, av, "<missing>")
+		default:
+			d.diff(elemPath, av, bv)
+		}
+	}
+}
+
+func nilOrEmpty(v reflect.Value) string {
+	if v.IsNil() {
+		return "nil"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// scalarEqual compares values of the same basic kind without calling Interface,
+// which panics on unexported fields.
+func scalarEqual(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	}
+	return false
+}
@@ -1,5 +1,10 @@
 package sugar
 
+import (
+	"errors"
+	"fmt"
+)
+
 //Try calls the function and return false in case of error.
 func Try(callback func() error) (ok bool) {
 	ok = true
@@ -18,3 +23,156 @@ func Try(callback func() error) (ok bool) {
 
 	return
 }
+
+// IndexError reports the index of the collection element whose callback failed.
+type IndexError struct {
+	Index int
+	Err   error
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("index %d: %v", e.Index, e.Err)
+}
+
+func (e *IndexError) Unwrap() error {
+	return e.Err
+}
+
+// MapErr is like Map but stops at the first failing iteratee and returns its error wrapped in an *IndexError.
+func MapErr[T any, R any](collection []T, iteratee func(T, int) (R, error)) ([]R, error) {
+	result := make([]R, len(collection))
+
+	for i, item := range collection {
+		value, err := iteratee(item, i)
+		if err != nil {
+			return nil, &IndexError{Index: i, Err: err}
+		}
+		result[i] = value
+	}
+
+	return result, nil
+}
+
+// MapErrAll is like MapErr but visits every element. Failed elements are left as zero values
+// and all errors are returned joined with errors.Join.
+func MapErrAll[T any, R any](collection []T, iteratee func(T, int) (R, error)) ([]R, error) {
+	result := make([]R, len(collection))
+	var errs []error
+
+	for i, item := range collection {
+		value, err := iteratee(item, i)
+		if err != nil {
+			errs = append(errs, &IndexError{Index: i, Err: err})
+			continue
+		}
+		result[i] = value
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// FilterErr is like Filter but stops at the first failing predicate and returns its error wrapped in an *IndexError.
+func FilterErr[T any](collection []T, predicate func(T, int) (bool, error)) ([]T, error) {
+	result := make([]T, 0, len(collection))
+
+	for i, item := range collection {
+		ok, err := predicate(item, i)
+		if err != nil {
+			return nil, &IndexError{Index: i, Err: err}
+		}
+		if ok {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// FilterErrAll is like FilterErr but visits every element. Failed elements are excluded
+// and all errors are returned joined with errors.Join.
+func FilterErrAll[T any](collection []T, predicate func(T, int) (bool, error)) ([]T, error) {
+	result := make([]T, 0, len(collection))
+	var errs []error
+
+	for i, item := range collection {
+		ok, err := predicate(item, i)
+		if err != nil {
+			errs = append(errs, &IndexError{Index: i, Err: err})
+			continue
+		}
+		if ok {
+			result = append(result, item)
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// ReduceErr is like Reduce but stops at the first failing accumulator and returns its error wrapped in an *IndexError.
+func ReduceErr[T any, R any](collection []T, accumulator func(R, T, int) (R, error), initial R) (R, error) {
+	for i, item := range collection {
+		next, err := accumulator(initial, item, i)
+		if err != nil {
+			var zero R
+			return zero, &IndexError{Index: i, Err: err}
+		}
+		initial = next
+	}
+
+	return initial, nil
+}
+
+// ReduceErrAll is like ReduceErr but visits every element. Failed elements do not contribute
+// to the accumulated value and all errors are returned joined with errors.Join.
+func ReduceErrAll[T any, R any](collection []T, accumulator func(R, T, int) (R, error), initial R) (R, error) {
+	var errs []error
+
+	for i, item := range collection {
+		next, err := accumulator(initial, item, i)
+		if err != nil {
+			errs = append(errs, &IndexError{Index: i, Err: err})
+			continue
+		}
+		initial = next
+	}
+
+	return initial, errors.Join(errs...)
+}
+
+// FindErr is like Find but stops at the first failing predicate and returns its error wrapped in an *IndexError.
+func FindErr[T any](collection []T, predicate func(T) (bool, error)) (T, bool, error) {
+	var zero T
+
+	for i, item := range collection {
+		ok, err := predicate(item)
+		if err != nil {
+			return zero, false, &IndexError{Index: i, Err: err}
+		}
+		if ok {
+			return item, true, nil
+		}
+	}
+
+	return zero, false, nil
+}
+
+// FindErrAll is like FindErr but skips the elements whose predicate fails and keeps searching.
+// It returns the first matching element, if any, along with the errors of the elements
+// visited before it joined with errors.Join.
+func FindErrAll[T any](collection []T, predicate func(T) (bool, error)) (T, bool, error) {
+	var errs []error
+
+	for i, item := range collection {
+		ok, err := predicate(item)
+		if err != nil {
+			errs = append(errs, &IndexError{Index: i, Err: err})
+			continue
+		}
+		if ok {
+			return item, true, errors.Join(errs...)
+		}
+	}
+
+	var zero T
+	return zero, false, errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package sugar
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestMapErr(t *testing.T) {
+	_, err := MapErr([]string{"1", "x", "3"}, func(s string, _ int) (int, error) {
+		return strconv.Atoi(s)
+	})
+
+	var indexErr *IndexError
+	if !errors.As(err, &indexErr) || indexErr.Index != 1 {
+		t.Fatalf("expected failure at index 1, got %v", err)
+	}
+}
+
+func TestMapErrAll(t *testing.T) {
+	got, err := MapErrAll([]string{"1", "x", "3", "y"}, func(s string, _ int) (int, error) {
+		return strconv.Atoi(s)
+	})
+	if err == nil || got[0] != 1 || got[2] != 3 {
+		t.Fatalf("unexpected result %v, %v", got, err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Fatalf("expected 2 errors, got %d", n)
+	}
+}
+
+func TestReduceErrWithMust(t *testing.T) {
+	sum := Must(ReduceErr([]string{"1", "2", "3"}, func(acc int, s string, _ int) (int, error) {
+		n, err := strconv.Atoi(s)
+		return acc + n, err
+	}, 0))
+	if sum != 6 {
+		t.Fatalf("unexpected sum %d", sum)
+	}
+}
+
+func TestFindErrAll(t *testing.T) {
+	got, ok, err := FindErrAll([]string{"x", "2", "y", "4"}, func(s string) (bool, error) {
+		n, err := strconv.Atoi(s)
+		return n%2 == 0, err
+	})
+	var indexErr *IndexError
+	if !ok || got != "2" || !errors.As(err, &indexErr) || indexErr.Index != 0 {
+		t.Fatalf("unexpected result %q, %v, %v", got, ok, err)
+	}
+}
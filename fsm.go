@@ -0,0 +1,503 @@
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnknownState is returned when a state is used before being added to an FSM.
+	ErrUnknownState = errors.New("sugar: unknown state")
+	// ErrDuplicateState is returned when adding a state twice to an FSM.
+	ErrDuplicateState = errors.New("sugar: state already exists")
+	// ErrInvalidEvent is wrapped by FSMError when no transition handles the event in the current state.
+	ErrInvalidEvent = errors.New("sugar: event not allowed in the current state")
+	// ErrGuardRejected is wrapped by FSMError when transitions handle the event but their guards all refused it.
+	ErrGuardRejected = errors.New("sugar: transition rejected by guard")
+)
+
+// FSMError reports an event the FSM refused in State. Err is ErrInvalidEvent or ErrGuardRejected.
+type FSMError[S, E comparable] struct {
+	State S
+	Event E
+	Err   error
+}
+
+func (e *FSMError[S, E]) Error() string {
+	return fmt.Sprintf("%v: event %v in state %v", e.Err, e.Event, e.State)
+}
+
+func (e *FSMError[S, E]) Unwrap() error {
+	return e.Err
+}
+
+// FSMEvent describes a transition being taken. It is passed to guards and callbacks.
+type FSMEvent[S, E comparable, C any] struct {
+	Event E
+	// From is the innermost active state when the event was fired.
+	From S
+	// To is the target of the transition, which may be a composite state.
+	To   S
+	Args []any
+	// Context points to the machine's context, which callbacks may modify.
+	Context *C
+}
+
+// StateConfig describes a state of an FSM.
+type StateConfig[S, E comparable, C any] struct {
+	OnEntry func(e FSMEvent[S, E, C])
+	OnExit  func(e FSMEvent[S, E, C])
+	// Initial makes the state the one entered with its parent. Defaults to the first substate added.
+	Initial bool
+	// History makes a composite state resume the innermost substate that was active when it was
+	// last exited, instead of starting over from its initial substate.
+	History bool
+}
+
+// FSMTransition moves the machine from From to To when Event is fired and Guard, if any, allows it.
+// A transition from a composite state applies to all of its substates.
+type FSMTransition[S, E comparable, C any] struct {
+	From   S
+	Event  E
+	To     S
+	Guard  func(e FSMEvent[S, E, C]) bool
+	Action func(e FSMEvent[S, E, C])
+}
+
+type fsmState[S, E comparable, C any] struct {
+	id       S
+	parent   *fsmState[S, E, C]
+	children []*fsmState[S, E, C]
+	initial  *fsmState[S, E, C]
+	config   StateConfig[S, E, C]
+}
+
+// ancestorOf reports whether s is other or one of its ancestors.
+func (s *fsmState[S, E, C]) ancestorOf(other *fsmState[S, E, C]) bool {
+	for ; other != nil; other = other.parent {
+		if other == s {
+			return true
+		}
+	}
+	return false
+}
+
+type fsmKey[S, E comparable] struct {
+	state S
+	event E
+}
+
+// FSM is a hierarchical finite state machine over states S and events E, carrying a context C.
+// Add the states and transitions first; the machine enters its initial state on Start or on the
+// first Fire. It is safe for concurrent use. Callbacks and guards run while the machine is locked,
+// so they must not call its methods; everything they need is in FSMEvent.
+type FSM[S, E comparable, C any] struct {
+	mu          sync.Mutex
+	initial     S
+	context     C
+	states      map[S]*fsmState[S, E, C]
+	roots       []*fsmState[S, E, C]
+	transitions map[fsmKey[S, E]][]FSMTransition[S, E, C]
+	order       []FSMTransition[S, E, C]
+	observers   []func(e FSMEvent[S, E, C])
+
+	config fsmConfig
+
+	started   bool
+	current   *fsmState[S, E, C]
+	lastLeaf  map[S]*fsmState[S, E, C]
+	seq       uint64
+	replaying bool
+}
+
+// NewFSM returns a machine that will start in initial with the given context.
+func NewFSM[S, E comparable, C any](initial S, context C, opts ...FSMOption) *FSM[S, E, C] {
+	config := fsmConfig{migrations: make(map[int]func([]byte) ([]byte, error))}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &FSM[S, E, C]{
+		initial:     initial,
+		context:     context,
+		states:      make(map[S]*fsmState[S, E, C]),
+		transitions: make(map[fsmKey[S, E]][]FSMTransition[S, E, C]),
+		config:      config,
+		lastLeaf:    make(map[S]*fsmState[S, E, C]),
+	}
+}
+
+// AddState adds a top-level state.
+func (m *FSM[S, E, C]) AddState(state S, config StateConfig[S, E, C]) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addState(nil, state, config)
+}
+
+// AddSubState adds state nested in parent, making parent a composite state.
+func (m *FSM[S, E, C]) AddSubState(parent, state S, config StateConfig[S, E, C]) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.states[parent]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownState, parent)
+	}
+	return m.addState(p, state, config)
+}
+
+// addState must be called with the lock held.
+func (m *FSM[S, E, C]) addState(parent *fsmState[S, E, C], state S, config StateConfig[S, E, C]) error {
+	if _, ok := m.states[state]; ok {
+		return fmt.Errorf("%w: %v", ErrDuplicateState, state)
+	}
+
+	s := &fsmState[S, E, C]{id: state, parent: parent, config: config}
+	m.states[state] = s
+	if parent == nil {
+		m.roots = append(m.roots, s)
+		return nil
+	}
+	parent.children = append(parent.children, s)
+	if parent.initial == nil || config.Initial {
+		parent.initial = s
+	}
+	return nil
+}
+
+// AddTransition adds a transition. Transitions are tried in the order they were added, those of
+// the innermost state first, and the first whose guard allows the event is taken.
+func (m *FSM[S, E, C]) AddTransition(t FSMTransition[S, E, C]) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, state := range []S{t.From, t.To} {
+		if _, ok := m.states[state]; !ok {
+			return fmt.Errorf("%w: %v", ErrUnknownState, state)
+		}
+	}
+	key := fsmKey[S, E]{t.From, t.Event}
+	m.transitions[key] = append(m.transitions[key], t)
+	m.order = append(m.order, t)
+	return nil
+}
+
+// OnTransition registers a callback invoked after every transition, once the target is entered.
+func (m *FSM[S, E, C]) OnTransition(callback func(e FSMEvent[S, E, C])) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observers = append(m.observers, callback)
+}
+
+// Start enters the initial state, running the entry callbacks. It does nothing if the machine already started.
+func (m *FSM[S, E, C]) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.start()
+}
+
+// start must be called with the lock held.
+func (m *FSM[S, E, C]) start() error {
+	if m.started {
+		return nil
+	}
+	initial, ok := m.states[m.initial]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownState, m.initial)
+	}
+
+	e := FSMEvent[S, E, C]{From: m.initial, To: m.initial, Context: &m.context}
+	m.enter(nil, initial, e)
+	m.current = m.descend(initial, e)
+	m.started = true
+	return nil
+}
+
+// State returns the innermost active state.
+func (m *FSM[S, E, C]) State() S {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.current == nil {
+		return m.initial
+	}
+	return m.current.id
+}
+
+// IsIn reports whether state is the innermost active state or one of its ancestors.
+func (m *FSM[S, E, C]) IsIn(state S) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.states[state]
+	return ok && m.current != nil && s.ancestorOf(m.current)
+}
+
+// Context returns a copy of the machine's context.
+func (m *FSM[S, E, C]) Context() C {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.context
+}
+
+// Can reports whether firing event in the current state would take a transition.
+func (m *FSM[S, E, C]) Can(event E, args ...any) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.start(); err != nil {
+		return false
+	}
+	_, err := m.find(event, args)
+	return err == nil
+}
+
+// AvailableEvents returns the events handled in the current state, guards not considered.
+func (m *FSM[S, E, C]) AvailableEvents() []E {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.start(); err != nil {
+		return nil
+	}
+
+	var events []E
+	for _, t := range m.order {
+		if m.states[t.From].ancestorOf(m.current) && !slices.Contains(events, t.Event) {
+			events = append(events, t.Event)
+		}
+	}
+	return events
+}
+
+// Fire takes the transition handling event in the current state. args are passed to the guards
+// and callbacks. It returns an *FSMError if the event is not allowed, or the error of the
+// FSMRecorder, in which case the transition has been taken but neither recorded nor counted in Seq.
+func (m *FSM[S, E, C]) Fire(event E, args ...any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.start(); err != nil {
+		return err
+	}
+	t, err := m.find(event, args)
+	if err != nil {
+		return err
+	}
+
+	from := m.current.id
+	m.take(t, args)
+	m.seq++
+	if err := m.record(from, event, args); err != nil {
+		m.seq--
+		return err
+	}
+	return nil
+}
+
+// find must be called with the lock held.
+func (m *FSM[S, E, C]) find(event E, args []any) (FSMTransition[S, E, C], error) {
+	rejected := false
+	for s := m.current; s != nil; s = s.parent {
+		for _, t := range m.transitions[fsmKey[S, E]{s.id, event}] {
+			if t.Guard == nil || t.Guard(m.event(t, args)) {
+				return t, nil
+			}
+			rejected = true
+		}
+	}
+
+	err := &FSMError[S, E]{State: m.current.id, Event: event, Err: ErrInvalidEvent}
+	if rejected {
+		err.Err = ErrGuardRejected
+	}
+	return FSMTransition[S, E, C]{}, err
+}
+
+// event must be called with the lock held.
+func (m *FSM[S, E, C]) event(t FSMTransition[S, E, C], args []any) FSMEvent[S, E, C] {
+	return FSMEvent[S, E, C]{
+		Event:   t.Event,
+		From:    m.current.id,
+		To:      t.To,
+		Args:    args,
+		Context: &m.context,
+	}
+}
+
+// take must be called with the lock held. The states are exited from the innermost one up to the
+// closest common ancestor of the source and the target, then the action runs and the states are
+// entered down to the target and its initial, or history, substates.
+func (m *FSM[S, E, C]) take(t FSMTransition[S, E, C], args []any) {
+	e := m.event(t, args)
+	target := m.states[t.To]
+
+	var domain *fsmState[S, E, C]
+	for a := target.parent; a != nil; a = a.parent {
+		if a.ancestorOf(m.current) {
+			domain = a
+			break
+		}
+	}
+
+	leaf := m.current
+	for s := leaf; s != domain; s = s.parent {
+		if s != leaf {
+			m.lastLeaf[s.id] = leaf
+		}
+		if s.config.OnExit != nil {
+			s.config.OnExit(e)
+		}
+	}
+
+	if t.Action != nil {
+		t.Action(e)
+	}
+
+	m.enter(domain, target, e)
+	m.current = m.descend(target, e)
+
+	for _, observer := range m.observers {
+		observer(e)
+	}
+}
+
+// enter must be called with the lock held. It enters the states from below domain down to target.
+func (m *FSM[S, E, C]) enter(domain, target *fsmState[S, E, C], e FSMEvent[S, E, C]) {
+	var path []*fsmState[S, E, C]
+	for s := target; s != domain; s = s.parent {
+		path = append(path, s)
+	}
+	for _, s := range slices.Backward(path) {
+		if s.config.OnEntry != nil {
+			s.config.OnEntry(e)
+		}
+	}
+}
+
+// descend must be called with the lock held. It enters the substates of s until reaching a
+// simple state, which it returns.
+func (m *FSM[S, E, C]) descend(s *fsmState[S, E, C], e FSMEvent[S, E, C]) *fsmState[S, E, C] {
+	for len(s.children) > 0 {
+		if leaf, ok := m.lastLeaf[s.id]; ok && s.config.History {
+			m.enter(s, leaf, e)
+			return leaf
+		}
+		s = s.initial
+		m.enter(s.parent, s, e)
+	}
+	return s
+}
+
+// DOT returns the machine as a Graphviz digraph. Composite states are drawn as clusters.
+func (m *FSM[S, E, C]) DOT() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("digraph fsm {\n\tcompound=true;\n\tnode [shape=box, style=rounded];\n")
+	b.WriteString("\t\"__start\" [shape=point];\n")
+	if initial, ok := m.states[m.initial]; ok {
+		fmt.Fprintf(&b, "\t\"__start\" -> %q;\n", fmt.Sprint(m.anchor(initial).id))
+	}
+
+	var write func(s *fsmState[S, E, C], indent string)
+	write = func(s *fsmState[S, E, C], indent string) {
+		name := fmt.Sprint(s.id)
+		if len(s.children) == 0 {
+			fmt.Fprintf(&b, "%s%q;\n", indent, name)
+			return
+		}
+		fmt.Fprintf(&b, "%ssubgraph %q {\n%s\tlabel=%q;\n", indent, "cluster_"+name, indent, name)
+		for _, child := range s.children {
+			write(child, indent+"\t")
+		}
+		fmt.Fprintf(&b, "%s}\n", indent)
+	}
+	for _, s := range m.roots {
+		write(s, "\t")
+	}
+
+	for _, t := range m.order {
+		from, to := m.states[t.From], m.states[t.To]
+		attrs := []string{fmt.Sprintf("label=%q", fsmLabel(t))}
+		if len(from.children) > 0 {
+			attrs = append(attrs, fmt.Sprintf("ltail=%q", "cluster_"+fmt.Sprint(from.id)))
+		}
+		if len(to.children) > 0 {
+			attrs = append(attrs, fmt.Sprintf("lhead=%q", "cluster_"+fmt.Sprint(to.id)))
+		}
+		fmt.Fprintf(&b, "\t%q -> %q [%s];\n",
+			fmt.Sprint(m.anchor(from).id), fmt.Sprint(m.anchor(to).id), strings.Join(attrs, ", "))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the machine as a Mermaid stateDiagram-v2.
+func (m *FSM[S, E, C]) Mermaid() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	fmt.Fprintf(&b, "\t[*] --> %s\n", mermaidID(m.initial))
+
+	var write func(s *fsmState[S, E, C], indent string)
+	write = func(s *fsmState[S, E, C], indent string) {
+		id, name := mermaidID(s.id), fmt.Sprint(s.id)
+		if id != name {
+			fmt.Fprintf(&b, "%sstate %q as %s\n", indent, name, id)
+		}
+		if len(s.children) == 0 {
+			if id == name {
+				fmt.Fprintf(&b, "%s%s\n", indent, id)
+			}
+			return
+		}
+		fmt.Fprintf(&b, "%sstate %s {\n", indent, id)
+		fmt.Fprintf(&b, "%s\t[*] --> %s\n", indent, mermaidID(s.initial.id))
+		for _, child := range s.children {
+			write(child, indent+"\t")
+		}
+		fmt.Fprintf(&b, "%s}\n", indent)
+	}
+	for _, s := range m.roots {
+		write(s, "\t")
+	}
+
+	for _, t := range m.order {
+		fmt.Fprintf(&b, "\t%s --> %s : %s\n", mermaidID(t.From), mermaidID(t.To), fsmLabel(t))
+	}
+	return b.String()
+}
+
+// anchor returns the simple state a composite state is entered through, for drawing edges.
+func (m *FSM[S, E, C]) anchor(s *fsmState[S, E, C]) *fsmState[S, E, C] {
+	for len(s.children) > 0 {
+		s = s.initial
+	}
+	return s
+}
+
+func fsmLabel[S, E comparable, C any](t FSMTransition[S, E, C]) string {
+	label := fmt.Sprint(t.Event)
+	if t.Guard != nil {
+		label += " [guarded]"
+	}
+	return label
+}
+
+// mermaidID turns a state into an identifier Mermaid accepts.
+func mermaidID(state any) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, fmt.Sprint(state))
+}
@@ -0,0 +1,248 @@
+package sugar
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrFSMVersion is returned when a snapshot cannot be brought to the machine's version,
+	// or when replaying a record written by another version.
+	ErrFSMVersion = errors.New("sugar: unsupported FSM version")
+	// ErrReplayDiverged is returned by FSM.Replay when replaying a record does not end in the recorded state.
+	ErrReplayDiverged = errors.New("sugar: replayed transition diverged from the log")
+	// ErrReplayGap is returned by FSM.Replay when the log misses the records following the machine's Seq.
+	ErrReplayGap = errors.New("sugar: transition log has missing records")
+)
+
+// FSMOption configures an FSM.
+type FSMOption func(*fsmConfig)
+
+type fsmConfig struct {
+	version    int
+	migrations map[int]func([]byte) ([]byte, error)
+	recorder   io.Writer
+}
+
+// FSMVersion sets the version of the machine definition written in snapshots and records.
+// Bump it whenever states or the context change incompatibly. Defaults to 0.
+func FSMVersion(version int) FSMOption {
+	return func(c *fsmConfig) {
+		c.version = version
+	}
+}
+
+// FSMMigration registers how to turn a JSON snapshot of version from into one of version from+1.
+// Restore chains migrations up to the machine's version.
+func FSMMigration(from int, migrate func(snapshot []byte) ([]byte, error)) FSMOption {
+	return func(c *fsmConfig) {
+		c.migrations[from] = migrate
+	}
+}
+
+// FSMRecorder writes every transition to w as a line of JSON, see FSMRecord.
+func FSMRecorder(w io.Writer) FSMOption {
+	return func(c *fsmConfig) {
+		c.recorder = w
+	}
+}
+
+// FSMRecord is an entry of the transition log written by FSMRecorder.
+// Args are encoded as JSON, so a replay receives them as the types encoding/json decodes into any.
+type FSMRecord[S, E comparable] struct {
+	Version int       `json:"version"`
+	Seq     uint64    `json:"seq"`
+	Event   E         `json:"event"`
+	From    S         `json:"from"`
+	To      S         `json:"to"`
+	Args    []any     `json:"args,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// FSMSnapshot is the persisted form of an FSM. Seq is the number of transitions taken,
+// so replaying a log after restoring a snapshot skips the records it already covers.
+type FSMSnapshot[S comparable, C any] struct {
+	Version int    `json:"version"`
+	Seq     uint64 `json:"seq"`
+	State   S      `json:"state"`
+	Context C      `json:"context"`
+	// History holds the [composite state, innermost substate] pairs remembered for history states.
+	History [][2]S `json:"history,omitempty"`
+}
+
+// record must be called with the lock held.
+func (m *FSM[S, E, C]) record(from S, event E, args []any) error {
+	if m.config.recorder == nil || m.replaying {
+		return nil
+	}
+
+	line, err := json.Marshal(FSMRecord[S, E]{
+		Version: m.config.version,
+		Seq:     m.seq,
+		Event:   event,
+		From:    from,
+		To:      m.current.id,
+		Args:    args,
+		Time:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.config.recorder.Write(append(line, '\n'))
+	return err
+}
+
+// Snapshot encodes the current state, context and history to JSON. C must be JSON-encodable.
+func (m *FSM[S, E, C]) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.start(); err != nil {
+		return nil, err
+	}
+
+	snapshot := FSMSnapshot[S, C]{
+		Version: m.config.version,
+		Seq:     m.seq,
+		State:   m.current.id,
+		Context: m.context,
+	}
+	// Walk the states in the order they were added so equal machines encode to equal snapshots.
+	var walk func(s *fsmState[S, E, C])
+	walk = func(s *fsmState[S, E, C]) {
+		if leaf, ok := m.lastLeaf[s.id]; ok {
+			snapshot.History = append(snapshot.History, [2]S{s.id, leaf.id})
+		}
+		for _, child := range s.children {
+			walk(child)
+		}
+	}
+	for _, s := range m.roots {
+		walk(s)
+	}
+	return json.Marshal(snapshot)
+}
+
+// Restore replaces the state, context and history with those of a snapshot, without running any
+// callback. Snapshots of an older version are migrated with the FSMMigration functions; newer
+// snapshots, or older ones without a migration path, are rejected with ErrFSMVersion.
+func (m *FSM[S, E, C]) Restore(data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	if header.Version > m.config.version {
+		return fmt.Errorf("%w: snapshot version %d is newer than machine version %d", ErrFSMVersion, header.Version, m.config.version)
+	}
+	for v := header.Version; v < m.config.version; v++ {
+		migrate, ok := m.config.migrations[v]
+		if !ok {
+			return fmt.Errorf("%w: no migration from version %d", ErrFSMVersion, v)
+		}
+		var err error
+		if data, err = migrate(data); err != nil {
+			return fmt.Errorf("sugar: migrating FSM snapshot from version %d: %w", v, err)
+		}
+	}
+
+	var snapshot FSMSnapshot[S, C]
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	current, ok := m.states[snapshot.State]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownState, snapshot.State)
+	}
+	if len(current.children) > 0 {
+		return fmt.Errorf("%w: %v is not a leaf state", ErrUnknownState, snapshot.State)
+	}
+	lastLeaf := make(map[S]*fsmState[S, E, C], len(snapshot.History))
+	for _, entry := range snapshot.History {
+		composite, ok := m.states[entry[0]]
+		if !ok {
+			return fmt.Errorf("%w: %v", ErrUnknownState, entry[0])
+		}
+		leaf, ok := m.states[entry[1]]
+		if !ok || len(leaf.children) > 0 || !composite.ancestorOf(leaf) {
+			return fmt.Errorf("%w: %v", ErrUnknownState, entry[1])
+		}
+		lastLeaf[entry[0]] = leaf
+	}
+
+	m.current = current
+	m.context = snapshot.Context
+	m.lastLeaf = lastLeaf
+	m.seq = snapshot.Seq
+	m.started = true
+	return nil
+}
+
+// Replay fires the events of a log written by FSMRecorder, skipping the records already covered
+// by the machine's Seq, so a log can be replayed onto a fresh machine or onto a restored snapshot.
+// Callbacks run as they did originally, which rebuilds the context; replayed transitions are not
+// recorded again. It stops with ErrReplayDiverged if a transition does not end where the log says,
+// with ErrReplayGap if records are missing, and with ErrFSMVersion on a record of another version:
+// migrations only apply to snapshots, so take a snapshot before changing the machine's version.
+func (m *FSM[S, E, C]) Replay(r io.Reader) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.start(); err != nil {
+		return err
+	}
+
+	m.replaying = true
+	defer func() {
+		m.replaying = false
+	}()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record FSMRecord[S, E]
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return err
+		}
+		if record.Seq <= m.seq {
+			continue
+		}
+		if record.Seq != m.seq+1 {
+			return fmt.Errorf("%w: expected record %d, got %d", ErrReplayGap, m.seq+1, record.Seq)
+		}
+		if record.Version != m.config.version {
+			return fmt.Errorf("%w: record %d version %d does not match machine version %d", ErrFSMVersion, record.Seq, record.Version, m.config.version)
+		}
+
+		t, err := m.find(record.Event, record.Args)
+		if err != nil {
+			return fmt.Errorf("sugar: replaying record %d: %w", record.Seq, err)
+		}
+		m.take(t, record.Args)
+		m.seq++
+		if m.current.id != record.To {
+			return fmt.Errorf("%w: record %d expected %v, got %v", ErrReplayDiverged, record.Seq, record.To, m.current.id)
+		}
+	}
+	return scanner.Err()
+}
+
+// Seq returns the number of transitions taken, including those restored from a snapshot.
+func (m *FSM[S, E, C]) Seq() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.seq
+}
@@ -0,0 +1,112 @@
+package sugar
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFSMSnapshotAndReplay(t *testing.T) {
+	var log bytes.Buffer
+	m := newJobFSM(t, FSMRecorder(&log))
+	m.Fire("start")
+	m.Fire("fetched")
+
+	snapshot, err := m.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Fire("pause")
+	m.Fire("resume")
+
+	replayed := newJobFSM(t)
+	if err := replayed.Replay(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if replayed.State() != "processing" || !slices.Equal(replayed.Context().Trace, m.Context().Trace) {
+		t.Fatalf("unexpected replayed machine %s %v", replayed.State(), replayed.Context().Trace)
+	}
+
+	restored := newJobFSM(t)
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if restored.State() != "processing" || restored.Seq() != 2 {
+		t.Fatalf("unexpected restored machine %s at %d", restored.State(), restored.Seq())
+	}
+	if err := restored.Replay(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Seq() != 4 || !slices.Equal(restored.Context().Trace, m.Context().Trace) {
+		t.Fatalf("expected only the tail of the log to be replayed, got %v", restored.Context().Trace)
+	}
+}
+
+func TestFSMReplayRejectsGapsAndVersions(t *testing.T) {
+	var log bytes.Buffer
+	m := newJobFSM(t, FSMRecorder(&log))
+	m.Fire("start")
+	m.Fire("fetched")
+	m.Fire("pause")
+
+	lines := bytes.SplitAfter(log.Bytes(), []byte("\n"))
+	gap := append(slices.Clone(lines[0]), lines[2]...)
+	if err := newJobFSM(t).Replay(bytes.NewReader(gap)); !errors.Is(err, ErrReplayGap) {
+		t.Fatalf("expected ErrReplayGap, got %v", err)
+	}
+
+	if err := newJobFSM(t, FSMVersion(1)).Replay(bytes.NewReader(log.Bytes())); !errors.Is(err, ErrFSMVersion) {
+		t.Fatalf("expected older records to be rejected, got %v", err)
+	}
+}
+
+func TestFSMSnapshotVersion(t *testing.T) {
+	old := newJobFSM(t)
+	old.Fire("start")
+	snapshot, _ := old.Snapshot()
+
+	if err := newJobFSM(t, FSMVersion(1)).Restore(snapshot); !errors.Is(err, ErrFSMVersion) {
+		t.Fatalf("expected missing migration, got %v", err)
+	}
+
+	migrated := newJobFSM(t, FSMVersion(1), FSMMigration(0, func(data []byte) ([]byte, error) {
+		return bytes.Replace(data, []byte(`"fetching"`), []byte(`"processing"`), 1), nil
+	}))
+	if err := migrated.Restore(snapshot); err != nil || migrated.State() != "processing" {
+		t.Fatalf("unexpected migration %v to %s", err, migrated.State())
+	}
+
+	newer, _ := migrated.Snapshot()
+	if err := newJobFSM(t).Restore(newer); !errors.Is(err, ErrFSMVersion) {
+		t.Fatalf("expected newer snapshot to be rejected, got %v", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestFSMRecorderFailureKeepsSeq(t *testing.T) {
+	m := newJobFSM(t, FSMRecorder(failingWriter{}))
+	if err := m.Fire("start"); err == nil {
+		t.Fatal("expected the recorder error")
+	}
+	if m.State() != "fetching" || m.Seq() != 0 {
+		t.Fatalf("expected the transition to be taken but not counted, got %s at %d", m.State(), m.Seq())
+	}
+}
+
+func TestFSMRestoreRejectsCompositeState(t *testing.T) {
+	snapshot := []byte(`{"version":0,"seq":1,"state":"running","context":{}}`)
+	if err := newJobFSM(t).Restore(snapshot); !errors.Is(err, ErrUnknownState) {
+		t.Fatalf("expected a composite state to be rejected, got %v", err)
+	}
+
+	snapshot = []byte(`{"version":0,"seq":1,"state":"paused","context":{},"history":[["running","running"]]}`)
+	if err := newJobFSM(t).Restore(snapshot); !errors.Is(err, ErrUnknownState) {
+		t.Fatalf("expected a composite history entry to be rejected, got %v", err)
+	}
+}
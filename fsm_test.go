@@ -0,0 +1,125 @@
+package sugar
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type job struct {
+	Retries int
+	Trace   []string
+}
+
+func newJobFSM(t *testing.T, opts ...FSMOption) *FSM[string, string, job] {
+	m := NewFSM[string, string]("idle", job{}, opts...)
+	trace := func(label string) func(e FSMEvent[string, string, job]) {
+		return func(e FSMEvent[string, string, job]) {
+			e.Context.Trace = append(e.Context.Trace, label)
+		}
+	}
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(m.AddState("idle", StateConfig[string, string, job]{}))
+	must(m.AddState("running", StateConfig[string, string, job]{
+		OnEntry: trace("+running"),
+		OnExit:  trace("-running"),
+		History: true,
+	}))
+	must(m.AddSubState("running", "fetching", StateConfig[string, string, job]{OnEntry: trace("+fetching")}))
+	must(m.AddSubState("running", "processing", StateConfig[string, string, job]{OnEntry: trace("+processing")}))
+	must(m.AddState("paused", StateConfig[string, string, job]{}))
+
+	must(m.AddTransition(FSMTransition[string, string, job]{From: "idle", Event: "start", To: "running"}))
+	must(m.AddTransition(FSMTransition[string, string, job]{From: "fetching", Event: "fetched", To: "processing"}))
+	must(m.AddTransition(FSMTransition[string, string, job]{From: "running", Event: "pause", To: "paused"}))
+	must(m.AddTransition(FSMTransition[string, string, job]{From: "paused", Event: "resume", To: "running"}))
+	must(m.AddTransition(FSMTransition[string, string, job]{
+		From:  "running",
+		Event: "retry",
+		To:    "fetching",
+		Guard: func(e FSMEvent[string, string, job]) bool {
+			return e.Context.Retries < 1
+		},
+		Action: func(e FSMEvent[string, string, job]) {
+			e.Context.Retries++
+		},
+	}))
+	return m
+}
+
+func TestFSM(t *testing.T) {
+	m := newJobFSM(t)
+
+	for _, event := range []string{"start", "fetched", "pause", "resume"} {
+		if err := m.Fire(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.State() != "processing" || !m.IsIn("running") {
+		t.Fatalf("expected history to resume processing, got %s", m.State())
+	}
+
+	want := []string{"+running", "+fetching", "+processing", "-running", "+running", "+processing"}
+	if trace := m.Context().Trace; !slices.Equal(trace, want) {
+		t.Fatalf("unexpected callbacks %v", trace)
+	}
+
+	if err := m.Fire("retry"); err != nil || m.State() != "fetching" {
+		t.Fatalf("unexpected retry %v in %s", err, m.State())
+	}
+
+	var fsmErr *FSMError[string, string]
+	if err := m.Fire("retry"); !errors.As(err, &fsmErr) || !errors.Is(err, ErrGuardRejected) {
+		t.Fatalf("expected guard rejection, got %v", err)
+	}
+	if err := m.Fire("start"); !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("expected invalid event, got %v", err)
+	}
+	if events := m.AvailableEvents(); !slices.Equal(events, []string{"fetched", "pause", "retry"}) {
+		t.Fatalf("unexpected available events %v", events)
+	}
+}
+
+func TestFSMConcurrent(t *testing.T) {
+	m := newJobFSM(t)
+	m.Fire("start")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				m.Fire("pause")
+				m.Fire("resume")
+				m.State()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !m.IsIn("running") && m.State() != "paused" {
+		t.Fatalf("unexpected state %s", m.State())
+	}
+}
+
+func TestFSMExport(t *testing.T) {
+	m := newJobFSM(t)
+
+	dot := m.DOT()
+	if !strings.Contains(dot, `subgraph "cluster_running"`) || !strings.Contains(dot, `"idle" -> "fetching" [label="start", lhead="cluster_running"]`) {
+		t.Fatalf("unexpected DOT output:\n%s", dot)
+	}
+
+	mermaid := m.Mermaid()
+	if !strings.Contains(mermaid, "state running {") || !strings.Contains(mermaid, "running --> fetching : retry [guarded]") {
+		t.Fatalf("unexpected Mermaid output:\n%s", mermaid)
+	}
+}
@@ -0,0 +1,496 @@
+package sugar
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrHistoryClosed is returned when using a HistoryStore after Close.
+var ErrHistoryClosed = errors.New("sugar: history store has been closed")
+
+// HistoryStore retains the messages published on a Topic so subscribers can replay them.
+// Implementations must be safe for concurrent use.
+type HistoryStore[T any] interface {
+	// Append stores msg. Offsets are appended in increasing order.
+	Append(msg Message[T]) error
+	// Read returns the retained messages whose offset is at least from, oldest first.
+	Read(from uint64) ([]Message[T], error)
+	// ReadSince returns the retained messages published at or after since, oldest first.
+	ReadSince(since time.Time) ([]Message[T], error)
+	// LastOffset returns the offset of the last appended message, or 0 if there is none.
+	LastOffset() uint64
+	Close() error
+}
+
+// HistoryRetention bounds what a HistoryStore keeps. Zero fields are not enforced.
+type HistoryRetention struct {
+	MaxCount int
+	MaxAge   time.Duration
+	// MaxBytes bounds the on-disk size of a FileHistory. MemoryHistory ignores it.
+	MaxBytes int64
+}
+
+// MemoryHistory is a HistoryStore keeping messages in a RingBuffer.
+type MemoryHistory[T any] struct {
+	retention HistoryRetention
+
+	mu     sync.RWMutex
+	buf    *RingBuffer[Message[T]]
+	last   uint64
+	closed bool
+}
+
+// NewMemoryHistory returns an empty MemoryHistory. MaxCount is the ring capacity and defaults to 1024.
+func NewMemoryHistory[T any](retention HistoryRetention) *MemoryHistory[T] {
+	if retention.MaxCount <= 0 {
+		retention.MaxCount = 1024
+	}
+	return &MemoryHistory[T]{
+		retention: retention,
+		buf:       NewRingBuffer[Message[T]](retention.MaxCount, Overwrite),
+	}
+}
+
+func (h *MemoryHistory[T]) Append(msg Message[T]) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrHistoryClosed
+	}
+	h.buf.Push(msg)
+	h.last = msg.Offset
+	h.expire(time.Now())
+	return nil
+}
+
+// expire must be called with the lock held.
+func (h *MemoryHistory[T]) expire(now time.Time) {
+	if h.retention.MaxAge <= 0 {
+		return
+	}
+	for {
+		oldest, ok := h.buf.PeekFront()
+		if !ok || now.Sub(oldest.Timestamp) <= h.retention.MaxAge {
+			return
+		}
+		h.buf.Pop()
+	}
+}
+
+func (h *MemoryHistory[T]) Read(from uint64) ([]Message[T], error) {
+	return h.filter(func(msg Message[T]) bool {
+		return msg.Offset >= from
+	})
+}
+
+func (h *MemoryHistory[T]) ReadSince(since time.Time) ([]Message[T], error) {
+	return h.filter(func(msg Message[T]) bool {
+		return !msg.Timestamp.Before(since)
+	})
+}
+
+func (h *MemoryHistory[T]) filter(predicate func(Message[T]) bool) ([]Message[T], error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHistoryClosed
+	}
+	h.expire(time.Now())
+	return SeqToSlice(SeqFilter(h.buf.Values(), func(msg Message[T], _ int) bool {
+		return predicate(msg)
+	})), nil
+}
+
+func (h *MemoryHistory[T]) LastOffset() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.last
+}
+
+func (h *MemoryHistory[T]) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	h.buf.Clear()
+	return nil
+}
+
+// FileHistoryOption configures a FileHistory.
+type FileHistoryOption func(*fileHistoryConfig)
+
+type fileHistoryConfig struct {
+	retention    HistoryRetention
+	segmentBytes int64
+	sync         bool
+}
+
+// FileHistoryRetention sets the retention enforced by the store. Files are removed a whole segment
+// at a time, so up to a full segment past the bounds stays on disk; Read and ReadSince still skip
+// the messages beyond MaxCount and MaxAge. MaxBytes is only enforced per segment.
+func FileHistoryRetention(retention HistoryRetention) FileHistoryOption {
+	return func(c *fileHistoryConfig) {
+		c.retention = retention
+	}
+}
+
+// FileHistorySegmentSize sets the size after which a new segment file is started. Defaults to 16 MiB.
+func FileHistorySegmentSize(n int64) FileHistoryOption {
+	return func(c *fileHistoryConfig) {
+		c.segmentBytes = n
+	}
+}
+
+// FileHistorySync makes every Append fsync the segment before returning.
+func FileHistorySync(sync bool) FileHistoryOption {
+	return func(c *fileHistoryConfig) {
+		c.sync = sync
+	}
+}
+
+const (
+	segmentExt         = ".log"
+	recordHeaderLength = 8
+)
+
+type historySegment struct {
+	path  string
+	base  uint64 // offset of the first message
+	next  uint64 // offset after the last message
+	count int
+	size  int64
+	last  time.Time
+}
+
+// FileHistory is a HistoryStore writing messages to an append-only log split into segment files
+// named after the offset of their first message. Every record carries its length and a CRC32
+// checksum; on open, a torn or corrupt tail left by a crash is truncated away.
+// Messages are encoded as JSON, so T must be JSON-encodable.
+type FileHistory[T any] struct {
+	dir    string
+	config fileHistoryConfig
+
+	mu       sync.Mutex
+	segments []*historySegment
+	active   *os.File
+	closed   bool
+}
+
+// NewFileHistory opens, or creates, the log stored in dir and recovers its state.
+func NewFileHistory[T any](dir string, opts ...FileHistoryOption) (*FileHistory[T], error) {
+	config := fileHistoryConfig{segmentBytes: 16 << 20}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	h := &FileHistory[T]{dir: dir, config: config}
+	if err := h.recover(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *FileHistory[T]) recover() error {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		h.segments = append(h.segments, &historySegment{
+			path: filepath.Join(h.dir, name),
+			base: base,
+			next: base,
+		})
+	}
+	sort.Slice(h.segments, func(i, j int) bool {
+		return h.segments[i].base < h.segments[j].base
+	})
+
+	for _, seg := range h.segments {
+		valid, err := scanSegment(seg.path, func(msg Message[T]) bool {
+			seg.count++
+			seg.next = msg.Offset + 1
+			seg.last = msg.Timestamp
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(seg.path); err != nil {
+			return err
+		} else if info.Size() != valid {
+			if err := os.Truncate(seg.path, valid); err != nil {
+				return err
+			}
+		}
+		seg.size = valid
+	}
+
+	if n := len(h.segments); n > 0 {
+		active, err := os.OpenFile(h.segments[n-1].path, os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		h.active = active
+	}
+	return nil
+}
+
+// scanSegment decodes the records of a segment file, stopping at the first incomplete or
+// corrupt one, and returns the size of the valid prefix.
+func scanSegment[T any](path string, fn func(Message[T]) bool) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(f)
+	var valid int64
+	header := make([]byte, recordHeaderLength)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return valid, nil
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		checksum := binary.BigEndian.Uint32(header[4:])
+		if int64(length) > info.Size()-valid-recordHeaderLength {
+			// A corrupt length must not make us allocate more than the file holds.
+			return valid, nil
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil || crc32.ChecksumIEEE(payload) != checksum {
+			return valid, nil
+		}
+
+		var msg Message[T]
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return valid, nil
+		}
+		valid += int64(recordHeaderLength) + int64(length)
+
+		if !fn(msg) {
+			return valid, nil
+		}
+	}
+}
+
+func (h *FileHistory[T]) Append(msg Message[T]) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, recordHeaderLength+len(payload))
+	binary.BigEndian.PutUint32(record[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderLength:], payload)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrHistoryClosed
+	}
+
+	seg := h.current()
+	if seg == nil || seg.size >= h.config.segmentBytes {
+		if seg, err = h.roll(msg.Offset); err != nil {
+			return err
+		}
+	}
+
+	if _, err := h.active.Write(record); err != nil {
+		return err
+	}
+	if h.config.sync {
+		if err := h.active.Sync(); err != nil {
+			return err
+		}
+	}
+
+	seg.count++
+	seg.size += int64(len(record))
+	seg.next = msg.Offset + 1
+	seg.last = msg.Timestamp
+
+	return h.enforceRetention(time.Now())
+}
+
+// current must be called with the lock held.
+func (h *FileHistory[T]) current() *historySegment {
+	if len(h.segments) == 0 {
+		return nil
+	}
+	return h.segments[len(h.segments)-1]
+}
+
+// roll must be called with the lock held.
+func (h *FileHistory[T]) roll(base uint64) (*historySegment, error) {
+	if h.active != nil {
+		if err := h.active.Close(); err != nil {
+			return nil, err
+		}
+		h.active = nil
+	}
+
+	path := filepath.Join(h.dir, fmt.Sprintf("%020d%s", base, segmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	seg := &historySegment{path: path, base: base, next: base}
+	h.active = f
+	h.segments = append(h.segments, seg)
+	return seg, nil
+}
+
+// enforceRetention must be called with the lock held. The active segment is never removed.
+func (h *FileHistory[T]) enforceRetention(now time.Time) error {
+	r := h.config.retention
+
+	var count int
+	var size int64
+	for _, seg := range h.segments {
+		count += seg.count
+		size += seg.size
+	}
+
+	for len(h.segments) > 1 {
+		oldest := h.segments[0]
+		expired := (r.MaxCount > 0 && count-oldest.count >= r.MaxCount) ||
+			(r.MaxAge > 0 && now.Sub(oldest.last) > r.MaxAge) ||
+			(r.MaxBytes > 0 && size > r.MaxBytes)
+		if !expired {
+			return nil
+		}
+
+		if err := os.Remove(oldest.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		count -= oldest.count
+		size -= oldest.size
+		h.segments = h.segments[1:]
+	}
+	return nil
+}
+
+func (h *FileHistory[T]) Read(from uint64) ([]Message[T], error) {
+	return h.read(func(seg *historySegment) bool {
+		return seg.next > from
+	}, func(msg Message[T]) bool {
+		return msg.Offset >= from
+	})
+}
+
+func (h *FileHistory[T]) ReadSince(since time.Time) ([]Message[T], error) {
+	return h.read(func(seg *historySegment) bool {
+		return !seg.last.Before(since)
+	}, func(msg Message[T]) bool {
+		return !msg.Timestamp.Before(since)
+	})
+}
+
+func (h *FileHistory[T]) read(segmentMatches func(*historySegment) bool, predicate func(Message[T]) bool) ([]Message[T], error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHistoryClosed
+	}
+	now := time.Now()
+	if err := h.enforceRetention(now); err != nil {
+		return nil, err
+	}
+
+	// The oldest segment may still hold messages past the retention bounds.
+	r := h.config.retention
+	skip := 0
+	if r.MaxCount > 0 {
+		for _, seg := range h.segments {
+			skip += seg.count
+		}
+		skip = max(skip-r.MaxCount, 0)
+	}
+	var cutoff time.Time
+	if r.MaxAge > 0 {
+		cutoff = now.Add(-r.MaxAge)
+	}
+
+	result := make([]Message[T], 0)
+	for _, seg := range h.segments {
+		if skip >= seg.count || !segmentMatches(seg) {
+			skip = max(skip-seg.count, 0)
+			continue
+		}
+		if _, err := scanSegment(seg.path, func(msg Message[T]) bool {
+			if skip > 0 {
+				skip--
+			} else if !msg.Timestamp.Before(cutoff) && predicate(msg) {
+				result = append(result, msg)
+			}
+			return true
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (h *FileHistory[T]) LastOffset() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if seg := h.current(); seg != nil && seg.next > 0 {
+		return seg.next - 1
+	}
+	return 0
+}
+
+func (h *FileHistory[T]) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	if h.active == nil {
+		return nil
+	}
+	return h.active.Close()
+}
@@ -0,0 +1,112 @@
+package sugar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryHistory(t *testing.T) {
+	h := NewMemoryHistory[int](HistoryRetention{MaxCount: 3})
+	for i := 1; i <= 5; i++ {
+		h.Append(Message[int]{Offset: uint64(i), Payload: i, Timestamp: time.Now()})
+	}
+
+	msgs, _ := h.Read(4)
+	if len(msgs) != 2 || msgs[0].Payload != 4 || h.LastOffset() != 5 {
+		t.Fatalf("unexpected history %v", msgs)
+	}
+	if msgs, _ := h.Read(0); len(msgs) != 3 {
+		t.Fatalf("expected 3 retained messages, got %d", len(msgs))
+	}
+}
+
+func TestFileHistoryRecovery(t *testing.T) {
+	dir := t.TempDir()
+
+	h, err := NewFileHistory[string](dir, FileHistorySegmentSize(128))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, payload := range []string{"a", "b", "c", "d"} {
+		if err := h.Append(Message[string]{Offset: uint64(i + 1), Payload: payload, Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(segments) < 2 {
+		t.Fatalf("expected several segments, got %v", segments)
+	}
+
+	// Simulate a crash in the middle of a write.
+	f, _ := os.OpenFile(segments[len(segments)-1], os.O_WRONLY|os.O_APPEND, 0o644)
+	f.Write([]byte{0xff, 0xff, 0xff, 0xff, 1, 2})
+	f.Close()
+
+	topic := NewTopicWithHistory[string]("log", mustFileHistory(t, dir))
+	if err := topic.Publish("e"); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := topic.Subscribe(1, "resume", SubscriptionFromOffset(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"c", "d", "e"} {
+		if msg := <-sub.Inbox; msg.Payload != want {
+			t.Fatalf("expected %s, got %+v", want, msg)
+		}
+	}
+}
+
+func TestFileHistoryRetention(t *testing.T) {
+	h, err := NewFileHistory[int](t.TempDir(), FileHistorySegmentSize(1), FileHistoryRetention(HistoryRetention{MaxCount: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	for i := 1; i <= 5; i++ {
+		h.Append(Message[int]{Offset: uint64(i), Payload: i, Timestamp: time.Now()})
+	}
+	msgs, _ := h.Read(0)
+	if len(msgs) != 2 || msgs[0].Offset != 4 {
+		t.Fatalf("unexpected retained messages %v", msgs)
+	}
+}
+
+func TestFileHistoryRetentionWithinSegment(t *testing.T) {
+	h, err := NewFileHistory[int](t.TempDir(), FileHistoryRetention(HistoryRetention{MaxCount: 2, MaxAge: time.Hour}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	h.Append(Message[int]{Offset: 1, Payload: 1, Timestamp: time.Now().Add(-2 * time.Hour)})
+	for i := 2; i <= 5; i++ {
+		h.Append(Message[int]{Offset: uint64(i), Payload: i, Timestamp: time.Now()})
+	}
+	if msgs, _ := h.Read(0); len(msgs) != 2 || msgs[0].Offset != 4 {
+		t.Fatalf("unexpected retained messages %v", msgs)
+	}
+	if msgs, _ := h.ReadSince(time.Time{}); len(msgs) != 2 || msgs[1].Offset != 5 {
+		t.Fatalf("unexpected retained messages %v", msgs)
+	}
+
+	h.Append(Message[int]{Offset: 6, Payload: 6, Timestamp: time.Now().Add(-2 * time.Hour)})
+	if msgs, _ := h.Read(0); len(msgs) != 1 || msgs[0].Offset != 5 {
+		t.Fatalf("expected expired messages to be skipped, got %v", msgs)
+	}
+}
+
+func mustFileHistory(t *testing.T, dir string) *FileHistory[string] {
+	h, err := NewFileHistory[string](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
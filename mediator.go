@@ -1,36 +1,241 @@
 package sugar
 
-//Mediator  a FSM implement
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var (
+	// ErrMediatorCycle is returned by Mediator.Register when the new route would let a change
+	// come back to the member it started from, which would recurse forever.
+	ErrMediatorCycle = errors.New("sugar: mediator route creates a cycle")
+	// ErrMediatorClosed is returned by Mediator.Changed after Close.
+	ErrMediatorClosed = errors.New("sugar: mediator has been closed")
+)
 
-type Mediator struct {
-	transitions map[Member][]Member
+// Member is a participant of a Mediator. When a member calls Mediator.Changed, every member
+// routed from it has Process called with its GetTransData.
+type Member[T any] interface {
+	Process(payload T) error
+	GetTransData() T
 }
 
-var (
-	gMediator *Mediator
+// MediatorError reports a member whose Process failed while handling a change of From.
+type MediatorError[T any] struct {
+	From Member[T]
+	To   Member[T]
+	Err  error
+}
+
+func (e *MediatorError[T]) Error() string {
+	return fmt.Sprintf("sugar: mediator member %T failed processing a change of %T: %v", e.To, e.From, e.Err)
+}
+
+func (e *MediatorError[T]) Unwrap() error {
+	return e.Err
+}
+
+// MediatorMode decides how Mediator.Changed runs the members.
+type MediatorMode int
+
+const (
+	// MediatorSync processes the members one after the other in the caller's goroutine.
+	MediatorSync MediatorMode = iota
+	// MediatorAsync processes each member in its own goroutine and returns right away.
+	MediatorAsync
 )
 
-func GetMediator() *Mediator {
-	if gMediator == nil {
-		gMediator = &Mediator{make(map[Member][]Member)}
+// MediatorOption configures a Mediator.
+type MediatorOption func(*mediatorConfig)
+
+type mediatorConfig struct {
+	mode    MediatorMode
+	onError func(err error)
+}
+
+// MediatorDispatch sets the dispatch mode. Defaults to MediatorSync.
+func MediatorDispatch(mode MediatorMode) MediatorOption {
+	return func(c *mediatorConfig) {
+		c.mode = mode
 	}
-	return gMediator
 }
 
-func (m *Mediator) Changed(i Member) {
-	for _, member := range m.transitions[i] {
-		member.Process(i.GetTransData())
+// MediatorOnError registers a callback receiving every *MediatorError. It is the only way
+// to observe failures in MediatorAsync mode.
+func MediatorOnError(callback func(err error)) MediatorOption {
+	return func(c *mediatorConfig) {
+		c.onError = callback
 	}
 }
 
-func (m *Mediator) RegisterMember(from, to Member) {
-	if m.transitions[from] == nil {
-		m.transitions[from] = make([]Member, 0)
+// Mediator routes the changes of a member to the members registered after it, so members
+// do not need to know each other. It is safe for concurrent use.
+type Mediator[T any] struct {
+	config mediatorConfig
+
+	mu     sync.RWMutex
+	routes map[Member[T]][]Member[T]
+	closed bool
+	wg     sync.WaitGroup // only added to with mu held, so Close cannot race with Changed
+}
+
+// NewMediator returns a Mediator without routes.
+func NewMediator[T any](opts ...MediatorOption) *Mediator[T] {
+	var config mediatorConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &Mediator[T]{
+		config: config,
+		routes: make(map[Member[T]][]Member[T]),
+	}
+}
+
+// Register routes the changes of from to to. It returns ErrMediatorCycle if to, directly or
+// through other routes, already forwards to from.
+func (m *Mediator[T]) Register(from, to Member[T]) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if from == to || m.reaches(to, from) {
+		return ErrMediatorCycle
+	}
+	if !slices.Contains(m.routes[from], to) {
+		m.routes[from] = append(m.routes[from], to)
 	}
-	m.transitions[from] = append(m.transitions[from], to)
+	return nil
 }
 
-type Member interface {
-	Process(a any)
-	GetTransData() any
+// reaches must be called with the lock held.
+func (m *Mediator[T]) reaches(from, to Member[T]) bool {
+	visited := make(map[Member[T]]bool)
+	stack := []Member[T]{from}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == to {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		stack = append(stack, m.routes[current]...)
+	}
+	return false
+}
+
+// Unregister removes the route from from to to and reports whether it existed.
+func (m *Mediator[T]) Unregister(from, to Member[T]) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.Index(m.routes[from], to)
+	if i < 0 {
+		return false
+	}
+	m.routes[from] = slices.Delete(m.routes[from], i, i+1)
+	if len(m.routes[from]) == 0 {
+		delete(m.routes, from)
+	}
+	return true
+}
+
+// Remove removes every route from or to member.
+func (m *Mediator[T]) Remove(member Member[T]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.routes, member)
+	for from, targets := range m.routes {
+		targets = slices.DeleteFunc(targets, func(to Member[T]) bool {
+			return to == member
+		})
+		if len(targets) == 0 {
+			delete(m.routes, from)
+		} else {
+			m.routes[from] = targets
+		}
+	}
+}
+
+// Targets returns the members the changes of from are routed to, in registration order.
+func (m *Mediator[T]) Targets(from Member[T]) []Member[T] {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.routes[from])
+}
+
+// Changed passes the GetTransData of member to the Process of every member routed from it.
+// In MediatorSync mode all members are processed even if some fail, and the failures are
+// returned joined as *MediatorError values. In MediatorAsync mode it returns nil right away;
+// use MediatorOnError and Wait to observe the outcome. It returns ErrMediatorClosed after Close.
+func (m *Mediator[T]) Changed(member Member[T]) error {
+	async := m.config.mode == MediatorAsync
+
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return ErrMediatorClosed
+	}
+	targets := slices.Clone(m.routes[member])
+	if async {
+		m.wg.Add(len(targets))
+	}
+	m.mu.RUnlock()
+
+	if len(targets) == 0 {
+		return nil
+	}
+	payload := member.GetTransData()
+
+	if async {
+		for _, to := range targets {
+			go func() {
+				defer m.wg.Done()
+				m.process(member, to, payload)
+			}()
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, to := range targets {
+		if err := m.process(member, to, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Mediator[T]) process(from, to Member[T], payload T) error {
+	err := to.Process(payload)
+	if err == nil {
+		return nil
+	}
+
+	err = &MediatorError[T]{From: from, To: to, Err: err}
+	if m.config.onError != nil {
+		m.config.onError(err)
+	}
+	return err
+}
+
+// Wait blocks until every change dispatched in MediatorAsync mode, including the changes
+// they triggered, has been processed. It must not run concurrently with a Changed call made
+// outside of a member's Process; use Close to shut the Mediator down.
+func (m *Mediator[T]) Wait() {
+	m.wg.Wait()
+}
+
+// Close stops accepting changes and waits for the ones dispatched in MediatorAsync mode.
+// Changes triggered by members still processing are rejected with ErrMediatorClosed.
+func (m *Mediator[T]) Close() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	m.wg.Wait()
 }
@@ -1,77 +1,171 @@
 package sugar
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
 )
 
 type CDDriver struct {
-	Data string
+	Data     string
+	mediator *Mediator[string]
 }
 
-func (c *CDDriver) GetTransData() any {
+func (c *CDDriver) GetTransData() string {
 	return c.Data
 }
 
-func (c *CDDriver) Process(a any) {
+func (c *CDDriver) Process(string) error {
 	c.Data = "music,image"
 	fmt.Printf("CDDriver: reading data %s\n", c.Data)
-	GetMediator().Changed(c)
+	return c.mediator.Changed(c)
 }
 
 type CPU struct {
-	Video string
-	Sound string
+	Video    string
+	Sound    string
+	mediator *Mediator[string]
 }
 
-func (c *CPU) Process(data any) {
-	sp := strings.Split(data.(string), ",")
-	c.Sound = sp[0]
-	c.Video = sp[1]
+func (c *CPU) Process(data string) error {
+	sound, video, ok := strings.Cut(data, ",")
+	if !ok {
+		return fmt.Errorf("malformed data %q", data)
+	}
+	c.Sound = sound
+	c.Video = video
 
 	fmt.Printf("CPU: split data with Sound %s, Video %s\n", c.Sound, c.Video)
-	GetMediator().Changed(c)
+	return c.mediator.Changed(c)
 }
 
-func (c *CPU) GetTransData() any {
-	return c
+func (c *CPU) GetTransData() string {
+	return c.Sound + "," + c.Video
 }
 
 type VideoCard struct {
-	Data string
+	Data     string
+	mediator *Mediator[string]
 }
 
-func (v *VideoCard) Process(data any) {
-	v.Data = data.(*CPU).Video
+func (v *VideoCard) Process(data string) error {
+	_, v.Data, _ = strings.Cut(data, ",")
 	fmt.Printf("VideoCard: display %s\n", v.Data)
-	GetMediator().Changed(v)
+	return v.mediator.Changed(v)
 }
 
-func (v *VideoCard) GetTransData() any {
+func (v *VideoCard) GetTransData() string {
 	return v.Data
 }
 
 type SoundCard struct {
-	Data string
+	Data     string
+	mediator *Mediator[string]
 }
 
-func (s *SoundCard) Process(data any) {
-	s.Data = data.(*CPU).Sound
+func (s *SoundCard) Process(data string) error {
+	s.Data, _, _ = strings.Cut(data, ",")
 	fmt.Printf("SoundCard: play %s\n", s.Data)
-	GetMediator().Changed(s)
+	return s.mediator.Changed(s)
 }
 
-func (s *SoundCard) GetTransData() any {
+func (s *SoundCard) GetTransData() string {
 	return s.Data
 }
 
 func TestMediator(t *testing.T) {
-	mediator := GetMediator()
-	cd := &CDDriver{Data: "music,image"}
-	cpu := &CPU{}
-	mediator.RegisterMember(cd, cpu)
-	mediator.RegisterMember(cpu, &VideoCard{})
-	mediator.RegisterMember(cpu, &SoundCard{})
-	cd.Process(nil)
+	mediator := NewMediator[string]()
+	cd := &CDDriver{Data: "music,image", mediator: mediator}
+	cpu := &CPU{mediator: mediator}
+	video := &VideoCard{mediator: mediator}
+	sound := &SoundCard{mediator: mediator}
+	mediator.Register(cd, cpu)
+	mediator.Register(cpu, video)
+	mediator.Register(cpu, sound)
+
+	if err := cd.Process(""); err != nil {
+		t.Fatal(err)
+	}
+	if video.Data != "image" || sound.Data != "music" {
+		t.Fatalf("unexpected outputs %q and %q", video.Data, sound.Data)
+	}
+
+	if err := mediator.Register(sound, cd); !errors.Is(err, ErrMediatorCycle) {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestMediatorError(t *testing.T) {
+	mediator := NewMediator[string]()
+	cd := &CDDriver{mediator: mediator}
+	cpu := &CPU{mediator: mediator}
+	sound := &SoundCard{mediator: mediator}
+	mediator.Register(sound, cpu)
+	mediator.Register(sound, cd)
+
+	sound.Data = "noise"
+	err := mediator.Changed(sound)
+
+	var merr *MediatorError[string]
+	if !errors.As(err, &merr) || merr.To != Member[string](cpu) {
+		t.Fatalf("expected the CPU to fail, got %v", err)
+	}
+	if cd.Data != "music,image" {
+		t.Fatal("expected the other members to still be processed")
+	}
+}
+
+func TestMediatorAsync(t *testing.T) {
+	var failures atomic.Int32
+	mediator := NewMediator[string](MediatorDispatch(MediatorAsync), MediatorOnError(func(error) {
+		failures.Add(1)
+	}))
+	cd := &CDDriver{Data: "music,image", mediator: mediator}
+	cpu := &CPU{mediator: mediator}
+	video := &VideoCard{mediator: mediator}
+	mediator.Register(cd, cpu)
+	mediator.Register(cpu, video)
+
+	if err := mediator.Changed(cd); err != nil {
+		t.Fatal(err)
+	}
+	mediator.Wait()
+
+	if video.Data != "image" || failures.Load() != 0 {
+		t.Fatalf("unexpected video %q with %d failures", video.Data, failures.Load())
+	}
+}
+
+type countingMember struct {
+	processed atomic.Int32
+}
+
+func (c *countingMember) Process(string) error {
+	c.processed.Add(1)
+	return nil
+}
+
+func (c *countingMember) GetTransData() string {
+	return ""
+}
+
+func TestMediatorClose(t *testing.T) {
+	mediator := NewMediator[string](MediatorDispatch(MediatorAsync))
+	from, to := &countingMember{}, &countingMember{}
+	mediator.Register(from, to)
+
+	for i := 0; i < 10; i++ {
+		go mediator.Changed(from)
+	}
+	mediator.Changed(from)
+	mediator.Close()
+	if to.processed.Load() == 0 {
+		t.Fatal("expected Close to wait for dispatched changes")
+	}
+
+	if err := mediator.Changed(from); !errors.Is(err, ErrMediatorClosed) {
+		t.Fatalf("expected ErrMediatorClosed, got %v", err)
+	}
 }
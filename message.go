@@ -1,92 +1,559 @@
 package sugar
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type Message struct {
-	// Contents
+var (
+	// ErrTopicClosed is returned when publishing to or subscribing on a closed Topic.
+	ErrTopicClosed = errors.New("sugar: topic has been closed")
+	// ErrNotSubscribed is returned when unsubscribing a Subscription the Topic does not know.
+	ErrNotSubscribed = errors.New("sugar: subscription does not belong to this topic")
+	// ErrSlowConsumer is reported by Subscription.Err when the SlowConsumerDisconnect policy cut off a subscriber.
+	ErrSlowConsumer = errors.New("sugar: subscriber disconnected for being too slow")
+	// ErrTopicDeleted is reported by Subscription.Err when the topic was deleted under the subscriber.
+	ErrTopicDeleted = errors.New("sugar: topic has been deleted")
+)
+
+// Message is a payload published on a Topic.
+type Message[T any] struct {
+	// Offset is the position of the message in its topic, starting at 1.
+	Offset    uint64
+	Topic     string
+	Payload   T
+	Timestamp time.Time
+	// ReplyTo is the subject a reply should be sent to, set for messages sent with Broker.Request.
+	ReplyTo string
+}
+
+type User struct {
+	ID   uint64
+	Name string
+}
+
+type Session struct {
+	User      User
+	Timestamp time.Time
 }
 
-type Subscription struct {
-	ch chan Message
+// SlowConsumerPolicy decides what Publish does when a subscriber's Inbox is full.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerBlock makes Publish wait until the subscriber has room.
+	SlowConsumerBlock SlowConsumerPolicy = iota
+	// SlowConsumerDrop discards the message for that subscriber only.
+	SlowConsumerDrop
+	// SlowConsumerDisconnect unsubscribes the subscriber and closes its Inbox.
+	SlowConsumerDisconnect
+)
+
+// TopicOption configures a Topic.
+type TopicOption func(*topicConfig)
 
-	Inbox chan Message
+type topicConfig struct {
+	historySize int
 }
 
-func (s *Subscription) Publish(msg Message) error {
-	if _, ok := <-s.ch; !ok {
-		return errors.New("Topic has been closed")
+// TopicHistory keeps the last n published messages in memory so late subscribers can replay them.
+func TopicHistory(n int) TopicOption {
+	return func(c *topicConfig) {
+		c.historySize = n
 	}
+}
 
-	s.ch <- msg
+// SubscriptionOption configures a Subscription.
+type SubscriptionOption func(*subscriptionConfig)
 
-	return nil
+type subscriptionConfig struct {
+	buffer    int
+	policy    SlowConsumerPolicy
+	replay    int
+	from      uint64
+	since     time.Time
+	onDeleted func(topic string)
 }
 
-type Topic struct {
-	Subscribers    []Session
-	MessageHistory []Message
-	ch             <-chan Message
+// SubscriptionBuffer sets the capacity of the Inbox. Defaults to 16.
+func SubscriptionBuffer(n int) SubscriptionOption {
+	return func(c *subscriptionConfig) {
+		c.buffer = n
+	}
 }
 
-func (t *Topic) Subscribe(uid uint64, name string) (Subscription, error) {
-	// Get session and create one if it's the first
-	s := Session{
-		User: User{
-			ID:   uid,
-			Name: name,
-		},
+// SubscriptionPolicy sets what happens when the Inbox is full. Defaults to SlowConsumerBlock.
+func SubscriptionPolicy(policy SlowConsumerPolicy) SubscriptionOption {
+	return func(c *subscriptionConfig) {
+		c.policy = policy
+	}
+}
+
+// SubscriptionReplay delivers up to the last n messages of the topic history before live ones.
+// A negative n replays the whole history.
+func SubscriptionReplay(n int) SubscriptionOption {
+	return func(c *subscriptionConfig) {
+		c.replay = n
+	}
+}
+
+// SubscriptionFromOffset delivers the retained messages whose offset is at least offset
+// before live ones, so a consumer can resume after the last offset it processed.
+func SubscriptionFromOffset(offset uint64) SubscriptionOption {
+	return func(c *subscriptionConfig) {
+		c.from = offset
+	}
+}
+
+// SubscriptionSince delivers the retained messages published at or after since before live ones.
+func SubscriptionSince(since time.Time) SubscriptionOption {
+	return func(c *subscriptionConfig) {
+		c.since = since
+	}
+}
+
+// SubscriptionOnTopicDeleted registers a callback invoked with the topic name when a topic
+// the subscription receives from is deleted.
+func SubscriptionOnTopicDeleted(callback func(topic string)) SubscriptionOption {
+	return func(c *subscriptionConfig) {
+		c.onDeleted = callback
+	}
+}
+
+func newSubscriptionConfig(opts []SubscriptionOption) subscriptionConfig {
+	config := subscriptionConfig{buffer: 16}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// TopicStats is a snapshot of a Topic's counters.
+type TopicStats struct {
+	Subscribers int
+	Published   uint64
+	// Dropped counts deliveries that did not reach a subscriber's Inbox.
+	Dropped uint64
+}
+
+// Topic is an in-process publish/subscribe channel delivering every message to all of its subscribers.
+// It is safe for concurrent use.
+type Topic[T any] struct {
+	name string
+
+	pubMu   sync.Mutex // serializes Publish so every subscriber sees messages in offset order
+	mu      sync.RWMutex
+	subs    []*Subscription[T]
+	history HistoryStore[T]
+	offset  uint64
+	closed  bool
+
+	published atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// NewTopic returns an open Topic.
+func NewTopic[T any](name string, opts ...TopicOption) *Topic[T] {
+	var config topicConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var history HistoryStore[T]
+	if config.historySize > 0 {
+		history = NewMemoryHistory[T](HistoryRetention{MaxCount: config.historySize})
+	}
+	return &Topic[T]{name: name, history: history}
+}
+
+// NewTopicWithHistory returns an open Topic keeping the published messages in store, e.g. a
+// FileHistory, so subscribers can replay them. The topic resumes numbering after the last stored
+// offset. The store must not be shared with another topic and is not closed by the topic.
+func NewTopicWithHistory[T any](name string, store HistoryStore[T]) *Topic[T] {
+	return &Topic[T]{name: name, history: store, offset: store.LastOffset()}
+}
+
+// Name returns the name the topic was created with.
+func (t *Topic[T]) Name() string {
+	return t.name
+}
+
+// Publish delivers payload to every current subscriber according to their SlowConsumerPolicy.
+// If the topic has a history store and appending to it fails, nothing is delivered and the error is returned.
+func (t *Topic[T]) Publish(payload T) error {
+	return t.publish(payload, "")
+}
+
+func (t *Topic[T]) publish(payload T, replyTo string) error {
+	t.pubMu.Lock()
+	defer t.pubMu.Unlock()
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return ErrTopicClosed
+	}
+	msg := Message[T]{
+		Offset:    t.offset + 1,
+		Topic:     t.name,
+		Payload:   payload,
 		Timestamp: time.Now(),
+		ReplyTo:   replyTo,
 	}
+	if t.history != nil {
+		if err := t.history.Append(msg); err != nil {
+			t.mu.Unlock()
+			return err
+		}
+	}
+	t.offset = msg.Offset
+	subs := append([]*Subscription[T](nil), t.subs...)
+	t.mu.Unlock()
 
-	// Add session to the Topic & MessageHistory
-	t.Subscribers = append(t.Subscribers, s)
-	// Create a subscription
-	return Subscription{}, nil
+	t.published.Add(1)
+	for _, sub := range subs {
+		if !sub.deliver(msg) {
+			t.dropped.Add(1)
+		}
+	}
+
+	return nil
 }
 
-func (t *Topic) Unsubscribe(Subscription) error {
-	// Implementation
+// Subscribe registers a new subscriber for user uid.
+func (t *Topic[T]) Subscribe(uid uint64, name string, opts ...SubscriptionOption) (*Subscription[T], error) {
+	config := newSubscriptionConfig(opts)
+
+	// Holding pubMu guarantees no message is published between the replay and the live feed.
+	t.pubMu.Lock()
+	defer t.pubMu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, ErrTopicClosed
+	}
+
+	replay, err := t.replay(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := newSubscription(t, uid, name, config, replay)
+	t.subs = append(t.subs, sub)
+
+	return sub, nil
+}
+
+// replay must be called with the lock held.
+func (t *Topic[T]) replay(config subscriptionConfig) ([]Message[T], error) {
+	if t.history == nil {
+		return nil, nil
+	}
+
+	switch {
+	case config.from > 0:
+		return t.history.Read(config.from)
+	case !config.since.IsZero():
+		return t.history.ReadSince(config.since)
+	case config.replay != 0:
+		replay, err := t.history.Read(0)
+		if config.replay > 0 && config.replay < len(replay) {
+			replay = replay[len(replay)-config.replay:]
+		}
+		return replay, err
+	}
+	return nil, nil
+}
+
+// attach adds a subscription owned by someone else, such as a Broker pattern subscription.
+func (t *Topic[T]) attach(sub *Subscription[T]) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return ErrTopicClosed
+	}
+	t.subs = append(t.subs, sub)
 	return nil
 }
 
-func (t *Topic) Delete() error {
-	// Implementation
+// Unsubscribe removes sub from the topic and closes its Inbox.
+func (t *Topic[T]) Unsubscribe(sub *Subscription[T]) error {
+	if sub.owner != subscriptionOwner[T](t) {
+		return ErrNotSubscribed
+	}
+	return sub.Unsubscribe()
+}
+
+func (t *Topic[T]) remove(sub *Subscription[T]) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, s := range t.subs {
+		if s == sub {
+			t.subs = append(t.subs[:i], t.subs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribers returns the sessions of the current subscribers.
+func (t *Topic[T]) Subscribers() []Session {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return Map(t.subs, func(s *Subscription[T], _ int) Session {
+		return s.Session
+	})
+}
+
+// Stats returns the current counters of the topic.
+func (t *Topic[T]) Stats() TopicStats {
+	t.mu.RLock()
+	subscribers := len(t.subs)
+	t.mu.RUnlock()
+
+	return TopicStats{
+		Subscribers: subscribers,
+		Published:   t.published.Load(),
+		Dropped:     t.dropped.Load(),
+	}
+}
+
+// MessageHistory returns the retained messages, oldest first.
+// It returns an empty slice if the topic keeps no history or the store cannot be read.
+func (t *Topic[T]) MessageHistory() []Message[T] {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.history == nil {
+		return []Message[T]{}
+	}
+	history, err := t.history.Read(0)
+	if err != nil {
+		return []Message[T]{}
+	}
+	return history
+}
+
+// Close stops accepting messages, waits until every subscriber of the topic has received the
+// messages buffered for it or ctx is done, and then closes all Inboxes. Broker pattern
+// subscriptions are left running for the other topics.
+func (t *Topic[T]) Close(ctx context.Context) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return ErrTopicClosed
+	}
+	t.closed = true
+	subs := Filter(t.subs, func(s *Subscription[T], _ int) bool {
+		return s.owner == subscriptionOwner[T](t)
+	})
+	t.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.seal()
+	}
+	for _, sub := range subs {
+		select {
+		case <-sub.drained:
+		case <-ctx.Done():
+			t.shutdown(nil)
+			return context.Cause(ctx)
+		}
+	}
+
+	t.shutdown(nil)
 	return nil
 }
 
-type User struct {
-	ID   uint64
-	Name string
+// Delete closes the topic right away. Subscribers are notified through SubscriptionOnTopicDeleted;
+// those owned by the topic get their Inbox closed, even if messages are still buffered,
+// with Err reporting ErrTopicDeleted.
+func (t *Topic[T]) Delete() error {
+	t.shutdown(ErrTopicDeleted)
+	return nil
 }
 
-type Session struct {
-	User      User
-	Timestamp time.Time
+func (t *Topic[T]) shutdown(err error) {
+	t.mu.Lock()
+	t.closed = true
+	subs := t.subs
+	t.subs = nil
+	t.mu.Unlock()
+
+	for _, sub := range subs {
+		if err != nil && sub.onDeleted != nil {
+			sub.onDeleted(t.name)
+		}
+		if sub.owner == subscriptionOwner[T](t) {
+			sub.close(err)
+		}
+	}
 }
 
-var (
-	gSubscription Subscription
-	gTopic        Topic
-	c             = make(chan Message)
-)
+// subscriptionOwner is what a Subscription detaches from when it ends: a Topic or a Broker.
+type subscriptionOwner[T any] interface {
+	remove(sub *Subscription[T]) bool
+}
 
-func work() {
+// Subscription is a subscriber's handle on a Topic.
+type Subscription[T any] struct {
+	Session Session
+	// Inbox receives the published messages. It is closed when the subscription ends, dropping the
+	// messages still buffered unless the topic was closed with Topic.Close.
+	Inbox <-chan Message[T]
 
-	gSubscription.ch = c
-	gSubscription.Publish(Message{})
+	owner     subscriptionOwner[T]
+	inbox     chan Message[T] // buffers the messages forwarded to Inbox
+	policy    SlowConsumerPolicy
+	onDeleted func(topic string)
 
+	sendMu    sync.RWMutex
+	sealed    bool          // inbox is closed, guarded by sendMu
+	done      chan struct{} // closed when the subscription ends
+	drained   chan struct{} // closed once the forwarding to Inbox stopped
+	closeOnce sync.Once
+	err       error
 }
 
-func hub() {
+func newSubscription[T any](owner subscriptionOwner[T], uid uint64, name string, config subscriptionConfig, replay []Message[T]) *Subscription[T] {
+	// The Inbox is sized so the replay never blocks the subscriber creation.
+	inbox := make(chan Message[T], max(config.buffer, 0)+len(replay))
+	for _, msg := range replay {
+		inbox <- msg
+	}
+
+	out := make(chan Message[T])
+	s := &Subscription[T]{
+		Session: Session{
+			User: User{
+				ID:   uid,
+				Name: name,
+			},
+			Timestamp: time.Now(),
+		},
+		Inbox:     out,
+		owner:     owner,
+		inbox:     inbox,
+		policy:    config.policy,
+		onDeleted: config.onDeleted,
+		done:      make(chan struct{}),
+		drained:   make(chan struct{}),
+	}
+	go s.forward(out)
+	return s
+}
+
+// forward hands the buffered messages to the subscriber one at a time, so the end of the
+// buffer can be waited for, until the inbox is sealed and empty or the subscription ends.
+func (s *Subscription[T]) forward(out chan<- Message[T]) {
+	defer close(s.drained)
+	defer close(out)
+
 	for {
 		select {
-		case data := <-c:
-			gTopic.MessageHistory = append(gTopic.MessageHistory, data)
+		case msg, ok := <-s.inbox:
+			if !ok {
+				return
+			}
+			select {
+			case out <- msg:
+			case <-s.done:
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// seal stops accepting messages while letting the buffered ones reach the subscriber.
+func (s *Subscription[T]) seal() {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if !s.sealed {
+		s.sealed = true
+		close(s.inbox)
+	}
+}
+
+// Unsubscribe removes the subscription from its topic, or from its Broker, and closes the Inbox.
+func (s *Subscription[T]) Unsubscribe() error {
+	if !s.owner.remove(s) {
+		return ErrNotSubscribed
+	}
+	s.close(nil)
+	return nil
+}
+
+// Err returns why the subscription ended on its own, e.g. ErrSlowConsumer, or nil.
+func (s *Subscription[T]) Err() error {
+	s.sendMu.RLock()
+	defer s.sendMu.RUnlock()
+	return s.err
+}
+
+func (s *Subscription[T]) isClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
 
+// deliver sends msg according to the policy and reports whether it reached the Inbox.
+func (s *Subscription[T]) deliver(msg Message[T]) bool {
+	s.sendMu.RLock()
+	if s.sealed || s.isClosed() {
+		s.sendMu.RUnlock()
+		return false
+	}
+
+	switch s.policy {
+	case SlowConsumerBlock:
+		defer s.sendMu.RUnlock()
+		select {
+		case s.inbox <- msg:
+			return true
+		case <-s.done:
+			return false
+		}
+	default:
+		select {
+		case s.inbox <- msg:
+			s.sendMu.RUnlock()
+			return true
+		default:
 		}
 	}
+	s.sendMu.RUnlock()
 
+	if s.policy == SlowConsumerDisconnect {
+		s.owner.remove(s)
+		s.close(ErrSlowConsumer)
+	}
+	return false
+}
+
+func (s *Subscription[T]) close(err error) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.sendMu.Lock()
+		defer s.sendMu.Unlock()
+		s.err = err
+		if !s.sealed {
+			s.sealed = true
+			close(s.inbox)
+		}
+	})
+	<-s.drained
 }
@@ -0,0 +1,80 @@
+package sugar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTopicPublishSubscribe(t *testing.T) {
+	topic := NewTopic[string]("chat", TopicHistory(2))
+	topic.Publish("a")
+	topic.Publish("b")
+	topic.Publish("c")
+
+	late, err := topic.Subscribe(1, "late", SubscriptionReplay(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, _ := topic.Subscribe(2, "live")
+	topic.Publish("d")
+
+	for _, want := range []string{"b", "c", "d"} {
+		if msg := <-late.Inbox; msg.Payload != want {
+			t.Fatalf("expected %s, got %s", want, msg.Payload)
+		}
+	}
+	if msg := <-live.Inbox; msg.Payload != "d" || msg.Offset != 4 {
+		t.Fatalf("unexpected live message %+v", msg)
+	}
+
+	if err := live.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-live.Inbox; ok {
+		t.Fatal("expected inbox to be closed")
+	}
+	if len(topic.Subscribers()) != 1 {
+		t.Fatalf("unexpected subscribers %v", topic.Subscribers())
+	}
+}
+
+func TestTopicSlowConsumerPolicies(t *testing.T) {
+	topic := NewTopic[int]("numbers")
+	dropper, _ := topic.Subscribe(1, "dropper", SubscriptionBuffer(1), SubscriptionPolicy(SlowConsumerDrop))
+	slow, _ := topic.Subscribe(2, "slow", SubscriptionBuffer(1), SubscriptionPolicy(SlowConsumerDisconnect))
+
+	topic.Publish(1)
+	topic.Publish(2)
+
+	if msg := <-dropper.Inbox; msg.Payload != 1 || len(dropper.Inbox) != 0 {
+		t.Fatalf("expected second message to be dropped, got %+v", msg)
+	}
+	<-slow.Inbox
+	if _, ok := <-slow.Inbox; ok || !errors.Is(slow.Err(), ErrSlowConsumer) {
+		t.Fatalf("expected slow consumer to be disconnected, got %v", slow.Err())
+	}
+}
+
+func TestTopicCloseDrains(t *testing.T) {
+	topic := NewTopic[int]("jobs")
+	sub, _ := topic.Subscribe(1, "worker")
+	topic.Publish(1)
+	topic.Publish(2)
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		for range sub.Inbox {
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := topic.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := topic.Publish(3); !errors.Is(err, ErrTopicClosed) {
+		t.Fatalf("expected ErrTopicClosed, got %v", err)
+	}
+}
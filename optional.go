@@ -0,0 +1,214 @@
+package sugar
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Optional represents an optional value.
+// The zero Optional is empty. It encodes to JSON null and SQL NULL when empty.
+type Optional[T any] struct {
+	value   T
+	present bool
+}
+
+// Of returns an Optional holding value. It is the Optional counterpart of None
+// (Some is already taken by the collection predicate helper).
+func Of[T any](value T) Optional[T] {
+	return Optional[T]{value: value, present: true}
+}
+
+// None returns an empty Optional.
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// OfPtr returns an Optional holding the value pointed to by x, or an empty Optional if x is nil.
+func OfPtr[T any](x *T) Optional[T] {
+	if x == nil {
+		return None[T]()
+	}
+	return Of(*x)
+}
+
+// OfZeroable returns an Optional holding value, or an empty Optional if value is the zero value.
+func OfZeroable[T comparable](value T) Optional[T] {
+	var zero T
+	if value == zero {
+		return None[T]()
+	}
+	return Of(value)
+}
+
+// OfOk returns an Optional holding value if ok is true, mirroring the comma-ok idiom.
+func OfOk[T any](value T, ok bool) Optional[T] {
+	if !ok {
+		return None[T]()
+	}
+	return Of(value)
+}
+
+// IsPresent returns true if the optional contains a value.
+func (o Optional[T]) IsPresent() bool {
+	return o.present
+}
+
+// IsEmpty returns true if the optional is empty.
+func (o Optional[T]) IsEmpty() bool {
+	return !o.present
+}
+
+// Get returns the value if present, otherwise returns zero value.
+func (o Optional[T]) Get() T {
+	return o.value
+}
+
+// Unpack returns the value and whether it is present.
+func (o Optional[T]) Unpack() (T, bool) {
+	return o.value, o.present
+}
+
+// OrElse returns the value if present, otherwise returns the fallback.
+func (o Optional[T]) OrElse(fallback T) T {
+	if o.present {
+		return o.value
+	}
+	return fallback
+}
+
+// OrElseF returns the value if present, otherwise calls the fallback function.
+func (o Optional[T]) OrElseF(callback func() T) T {
+	if o.present {
+		return o.value
+	}
+	return callback()
+}
+
+// ToPtr returns a pointer to a copy of the value, or nil if the optional is empty.
+func (o Optional[T]) ToPtr() *T {
+	if !o.present {
+		return nil
+	}
+	return ToPtr(o.value)
+}
+
+// IfPresent calls callback with the value if present.
+func (o Optional[T]) IfPresent(callback func(T)) {
+	if o.present {
+		callback(o.value)
+	}
+}
+
+// Filter returns the optional if it holds a value predicate returns truthy for, otherwise an empty Optional.
+func (o Optional[T]) Filter(predicate func(T) bool) Optional[T] {
+	if o.present && predicate(o.value) {
+		return o
+	}
+	return None[T]()
+}
+
+// Map transforms the value if present. Use OptionalMap to change the value type.
+func (o Optional[T]) Map(iteratee func(T) T) Optional[T] {
+	return OptionalMap(o, iteratee)
+}
+
+// FlatMap chains an optional-returning operation on the value if present.
+// Use OptionalFlatMap to change the value type.
+func (o Optional[T]) FlatMap(iteratee func(T) Optional[T]) Optional[T] {
+	return OptionalFlatMap(o, iteratee)
+}
+
+// MarshalJSON encodes the value, or null if the optional is empty.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.present {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON decodes null as an empty optional and anything else as a present value.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = None[T]()
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*o = Of(value)
+	return nil
+}
+
+// Value implements driver.Valuer. An empty optional is stored as NULL.
+func (o Optional[T]) Value() (driver.Value, error) {
+	return sql.Null[T]{V: o.value, Valid: o.present}.Value()
+}
+
+// Scan implements sql.Scanner. NULL is scanned as an empty optional.
+func (o *Optional[T]) Scan(src any) error {
+	var n sql.Null[T]
+	if err := n.Scan(src); err != nil {
+		return err
+	}
+	*o = Optional[T]{value: n.V, present: n.Valid}
+	return nil
+}
+
+// OptionalMap transforms the value of o into another type if present.
+func OptionalMap[T any, R any](o Optional[T], iteratee func(T) R) Optional[R] {
+	if !o.present {
+		return None[R]()
+	}
+	return Of(iteratee(o.value))
+}
+
+// OptionalFlatMap chains an optional-returning operation producing another type if o is present.
+func OptionalFlatMap[T any, R any](o Optional[T], iteratee func(T) Optional[R]) Optional[R] {
+	if !o.present {
+		return None[R]()
+	}
+	return iteratee(o.value)
+}
+
+// FindOptional is like Find but reports "not found" as an empty Optional.
+func FindOptional[T any](collection []T, predicate func(T) bool) Optional[T] {
+	return OfOk(Find(collection, predicate))
+}
+
+// FindLastOptional is like FindLast but reports "not found" as an empty Optional.
+func FindLastOptional[T any](collection []T, predicate func(T) bool) Optional[T] {
+	return OfOk(FindLast(collection, predicate))
+}
+
+// CoalesceOptional is like Coalesce but reports "all zero" as an empty Optional.
+func CoalesceOptional[T comparable](values ...T) Optional[T] {
+	return OfOk(Coalesce(values...))
+}
+
+// MinOptional is like Min but returns an empty Optional for an empty collection.
+func MinOptional[T constraints.Ordered](collection []T) Optional[T] {
+	if len(collection) == 0 {
+		return None[T]()
+	}
+	return Of(Min(collection))
+}
+
+// MaxOptional is like Max but returns an empty Optional for an empty collection.
+func MaxOptional[T constraints.Ordered](collection []T) Optional[T] {
+	if len(collection) == 0 {
+		return None[T]()
+	}
+	return Of(Max(collection))
+}
+
+// GetOptional returns the value stored under key, or an empty Optional if HasKey would report false.
+func GetOptional[K comparable, V any](in map[K]V, key K) Optional[V] {
+	value, ok := in[key]
+	return OfOk(value, ok)
+}
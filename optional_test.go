@@ -0,0 +1,57 @@
+package sugar
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type optionalPayload struct {
+	Name Optional[string] `json:"name"`
+	Age  Optional[int]    `json:"age"`
+}
+
+func TestOptionalJSON(t *testing.T) {
+	data, err := json.Marshal(optionalPayload{Name: Of("sugar")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"name":"sugar","age":null}` {
+		t.Fatalf("unexpected json %s", data)
+	}
+
+	var decoded optionalPayload
+	if err := json.Unmarshal([]byte(`{"name":null,"age":3}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Name.IsPresent() || decoded.Age.OrElse(0) != 3 {
+		t.Fatalf("unexpected decode %+v", decoded)
+	}
+}
+
+func TestOptionalSQL(t *testing.T) {
+	var o Optional[int64]
+	if err := o.Scan(int64(5)); err != nil || o.Get() != 5 {
+		t.Fatalf("unexpected scan %v, %v", o, err)
+	}
+	if err := o.Scan(nil); err != nil || o.IsPresent() {
+		t.Fatalf("expected NULL to scan as empty, got %v, %v", o, err)
+	}
+	if v, err := o.Value(); err != nil || v != nil {
+		t.Fatalf("expected NULL value, got %v, %v", v, err)
+	}
+}
+
+func TestOptionalLookups(t *testing.T) {
+	if MaxOptional([]int{}).IsPresent() {
+		t.Fatal("expected empty max")
+	}
+
+	found := FindOptional([]int{1, 2, 3}, func(x int) bool { return x > 1 }).Map(func(x int) int { return x * 10 })
+	if found.Get() != 20 {
+		t.Fatalf("unexpected find %v", found.Get())
+	}
+
+	if GetOptional(map[string]int{"a": 0}, "a").IsEmpty() {
+		t.Fatal("expected zero value under existing key to be present")
+	}
+}
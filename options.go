@@ -0,0 +1,133 @@
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// OptionError reports the option that failed to apply. Option is its name, prefixed by the
+// names of the groups holding it, e.g. "tls/cert".
+type OptionError struct {
+	Option string
+	Err    error
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("option %s: %v", e.Option, e.Err)
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// Option is a named, possibly failing, change to a T, for the functional options pattern:
+//
+//	func WithTimeout(d time.Duration) Option[Client] {
+//		return NewOption("timeout", func(c *Client) { c.timeout = d })
+//	}
+type Option[T any] struct {
+	name  string
+	apply func(*T) error
+	group []Option[T]
+}
+
+// NewOption returns an option named name that cannot fail.
+func NewOption[T any](name string, apply func(*T)) Option[T] {
+	return NewFallibleOption(name, func(t *T) error {
+		apply(t)
+		return nil
+	})
+}
+
+// NewFallibleOption returns an option named name whose application may fail, e.g. to validate its argument.
+func NewFallibleOption[T any](name string, apply func(*T) error) Option[T] {
+	return Option[T]{name: name, apply: apply}
+}
+
+// GroupOptions returns an option named name applying opts in order, so related options can be passed as one.
+func GroupOptions[T any](name string, opts ...Option[T]) Option[T] {
+	return Option[T]{name: name, group: slices.Clone(opts)}
+}
+
+// Name returns the name the option was created with.
+func (o Option[T]) Name() string {
+	return o.name
+}
+
+// Apply applies the option to target, stopping at the first failure within a group.
+func (o Option[T]) Apply(target *T) error {
+	return o.run(target, "", nil, false)
+}
+
+// run applies o to target. It records the path of every applied option if trace is not nil,
+// and keeps going after a failure if all is true.
+func (o Option[T]) run(target *T, prefix string, trace *OptionTrace, all bool) error {
+	path := o.name
+	if prefix != "" {
+		path = prefix + "/" + o.name
+	}
+
+	if o.group == nil {
+		if o.apply == nil {
+			return nil
+		}
+		if err := o.apply(target); err != nil {
+			return &OptionError{Option: path, Err: err}
+		}
+		if trace != nil {
+			*trace = append(*trace, path)
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, opt := range o.group {
+		if err := opt.run(target, path, trace, all); err != nil {
+			if !all {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyOptions applies opts to target in order and returns the *OptionError of the first failing
+// one, leaving the following options unapplied.
+func ApplyOptions[T any](target *T, opts ...Option[T]) error {
+	return GroupOptions("", opts...).run(target, "", nil, false)
+}
+
+// ApplyOptionsAll applies every option to target and returns the errors of the failing ones joined.
+func ApplyOptionsAll[T any](target *T, opts ...Option[T]) error {
+	return GroupOptions("", opts...).run(target, "", nil, true)
+}
+
+// NewWithOptions returns a copy of defaults with opts applied, or the zero value and the error of
+// the first failing option.
+func NewWithOptions[T any](defaults T, opts ...Option[T]) (T, error) {
+	v := defaults
+	if err := ApplyOptions(&v, opts...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// OptionTrace lists the options applied by TraceOptions, in order, each named by its path
+// through the groups holding it, e.g. "tls/cert".
+type OptionTrace []string
+
+// Has reports whether the option with the given path was applied.
+func (t OptionTrace) Has(path string) bool {
+	return slices.Contains(t, path)
+}
+
+// TraceOptions is ApplyOptions, also recording which options were applied up to the failing one,
+// to introspect how a component was configured.
+func TraceOptions[T any](target *T, opts ...Option[T]) (OptionTrace, error) {
+	trace := OptionTrace{}
+	err := GroupOptions("", opts...).run(target, "", &trace, false)
+	return trace, err
+}
@@ -0,0 +1,56 @@
+package sugar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type client struct {
+	Addr    string
+	Timeout time.Duration
+	Retries int
+}
+
+func clientTimeout(d time.Duration) Option[client] {
+	return NewFallibleOption("timeout", func(c *client) error {
+		if d <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		c.Timeout = d
+		return nil
+	})
+}
+
+func clientRetries(n int) Option[client] {
+	return NewOption("retries", func(c *client) { c.Retries = n })
+}
+
+func TestOptions(t *testing.T) {
+	defaults := client{Addr: "localhost", Timeout: time.Second}
+	resilient := GroupOptions("resilient", clientTimeout(5*time.Second), clientRetries(3))
+
+	c, err := NewWithOptions(defaults, resilient)
+	if err != nil || c != (client{Addr: "localhost", Timeout: 5 * time.Second, Retries: 3}) {
+		t.Fatalf("unexpected client %+v: %v", c, err)
+	}
+
+	var optErr *OptionError
+	if _, err := NewWithOptions(defaults, clientTimeout(0), clientRetries(1)); !errors.As(err, &optErr) || optErr.Option != "timeout" {
+		t.Fatalf("expected timeout option error, got %v", err)
+	}
+
+	var target client
+	trace, err := TraceOptions(&target, clientRetries(2), GroupOptions("bad", clientTimeout(-1)), clientRetries(4))
+	if !errors.As(err, &optErr) || optErr.Option != "bad/timeout" {
+		t.Fatalf("expected grouped option error, got %v", err)
+	}
+	if !trace.Has("retries") || len(trace) != 1 || target.Retries != 2 {
+		t.Fatalf("unexpected trace %v", trace)
+	}
+
+	target = client{}
+	if err := ApplyOptionsAll(&target, clientTimeout(0), clientRetries(5)); err == nil || target.Retries != 5 {
+		t.Fatalf("expected every option to be applied, got %+v: %v", target, err)
+	}
+}
@@ -0,0 +1,182 @@
+package sugar
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// parallelFor runs fn for every index in [0, n) on at most limit goroutines.
+// The first error or panic cancels the context handed to the remaining calls;
+// errors are returned, panics are re-raised in the calling goroutine.
+func parallelFor(ctx context.Context, n int, limit int, fn func(context.Context, int) error) error {
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+	if limit > n {
+		limit = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		panicked bool
+		panicVal any
+	)
+
+	fail := func(err error, recovered any, isPanic bool) {
+		once.Do(func() {
+			firstErr = err
+			panicked = isPanic
+			panicVal = recovered
+			cancel()
+		})
+	}
+
+	indexes := make(chan int)
+
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							fail(nil, r, true)
+						}
+					}()
+
+					if err := fn(ctx, i); err != nil {
+						fail(err, nil, false)
+					}
+				}()
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if panicked {
+		panic(panicVal)
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return context.Cause(ctx)
+}
+
+// ParallelMap is the concurrent counterpart of Map. At most limit iteratees run at once
+// (GOMAXPROCS when limit <= 0) and the result keeps the order of collection.
+// The first error cancels the remaining work and is returned.
+func ParallelMap[T any, R any](ctx context.Context, collection []T, limit int, iteratee func(context.Context, T, int) (R, error)) ([]R, error) {
+	result := make([]R, len(collection))
+
+	err := parallelFor(ctx, len(collection), limit, func(ctx context.Context, i int) error {
+		value, err := iteratee(ctx, collection[i], i)
+		if err != nil {
+			return err
+		}
+		result[i] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ParallelFilter is the concurrent counterpart of Filter. The result keeps the order of collection.
+func ParallelFilter[T any](ctx context.Context, collection []T, limit int, predicate func(context.Context, T, int) (bool, error)) ([]T, error) {
+	keep, err := ParallelMap(ctx, collection, limit, predicate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]T, 0, len(collection))
+	for i, item := range collection {
+		if keep[i] {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// ParallelGroupBy is the concurrent counterpart of GroupBy. Keys are computed concurrently,
+// items inside each group keep the order of collection.
+func ParallelGroupBy[T any, U comparable](ctx context.Context, collection []T, limit int, iteratee func(context.Context, T) (U, error)) (map[U][]T, error) {
+	keys, err := ParallelMap(ctx, collection, limit, func(ctx context.Context, item T, _ int) (U, error) {
+		return iteratee(ctx, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[U][]T{}
+	for i, item := range collection {
+		result[keys[i]] = append(result[keys[i]], item)
+	}
+
+	return result, nil
+}
+
+// ParallelReduce is the concurrent counterpart of Reduce. collection is split into one contiguous
+// chunk per worker, each chunk is reduced starting from initial, and the partial results are folded
+// left to right with combine. initial must therefore be an identity element for combine.
+func ParallelReduce[T any, R any](ctx context.Context, collection []T, limit int, accumulator func(R, T, int) (R, error), combine func(R, R) R, initial R) (R, error) {
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+	if len(collection) == 0 {
+		return initial, nil
+	}
+
+	size := (len(collection) + limit - 1) / limit
+	chunks := Chunk(collection, size)
+	partials := make([]R, len(chunks))
+
+	err := parallelFor(ctx, len(chunks), limit, func(ctx context.Context, c int) error {
+		acc := initial
+		offset := c * size
+		for i, item := range chunks[c] {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var err error
+			acc, err = accumulator(acc, item, offset+i)
+			if err != nil {
+				return err
+			}
+		}
+		partials[c] = acc
+		return nil
+	})
+	if err != nil {
+		var zero R
+		return zero, err
+	}
+
+	result := partials[0]
+	for _, partial := range partials[1:] {
+		result = combine(result, partial)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package sugar
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestParallelMapKeepsOrder(t *testing.T) {
+	got, err := ParallelMap(context.Background(), Range(0, 100), 4, func(_ context.Context, x int, _ int) (string, error) {
+		return strconv.Itoa(x), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != "0" || got[99] != "99" {
+		t.Fatalf("unexpected result %v", got)
+	}
+}
+
+func TestParallelFilterError(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := ParallelFilter(context.Background(), Range(0, 100), 4, func(ctx context.Context, x int, _ int) (bool, error) {
+		if x == 10 {
+			return false, boom
+		}
+		return true, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+}
+
+func TestParallelMapPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "bad" {
+			t.Fatalf("expected panic to be propagated, got %v", r)
+		}
+	}()
+
+	_, _ = ParallelMap(context.Background(), Range(0, 10), 2, func(_ context.Context, x int, _ int) (int, error) {
+		if x == 5 {
+			panic("bad")
+		}
+		return x, nil
+	})
+}
+
+func TestParallelReduceGroupBy(t *testing.T) {
+	sum, err := ParallelReduce(context.Background(), Range(1, 101), 3, func(acc int, x int, _ int) (int, error) {
+		return acc + x, nil
+	}, func(a, b int) int { return a + b }, 0)
+	if err != nil || sum != 5050 {
+		t.Fatalf("unexpected sum %d, %v", sum, err)
+	}
+
+	groups, err := ParallelGroupBy(context.Background(), Range(0, 6), 3, func(_ context.Context, x int) (int, error) {
+		return x % 2, nil
+	})
+	if err != nil || !slices.Equal(groups[1], []int{1, 3, 5}) {
+		t.Fatalf("unexpected groups %v, %v", groups, err)
+	}
+}
@@ -0,0 +1,262 @@
+package sugar
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Generate returns a channel emitting values in order, closed after the last one or once ctx is done.
+func Generate[T any](ctx context.Context, values ...T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for _, v := range values {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// OrDone relays the values of in until in is closed or ctx is done, so callers can range
+// over a channel without also selecting on ctx.
+func OrDone[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Tee sends every value of in to both outputs. Each value is delivered to both
+// before the next one is read, so the slower reader sets the pace.
+func Tee[T any](ctx context.Context, in <-chan T) (<-chan T, <-chan T) {
+	out1 := make(chan T)
+	out2 := make(chan T)
+
+	go func() {
+		defer close(out1)
+		defer close(out2)
+
+		for v := range OrDone(ctx, in) {
+			o1, o2 := out1, out2
+			for i := 0; i < 2; i++ {
+				select {
+				case <-ctx.Done():
+					return
+				case o1 <- v:
+					o1 = nil
+				case o2 <- v:
+					o2 = nil
+				}
+			}
+		}
+	}()
+
+	return out1, out2
+}
+
+// Batch groups the values of in into slices of up to size values. A partial batch is
+// emitted once maxWait has passed since its first value when maxWait is greater than 0,
+// and when in is closed. Batches still being filled when ctx is done are dropped.
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	out := make(chan []T)
+	if size <= 0 {
+		size = 1
+	}
+
+	go func() {
+		defer close(out)
+
+		var (
+			batch    []T
+			deadline <-chan time.Time
+			timer    *time.Timer
+		)
+
+		stopTimer := func() {
+			if timer != nil {
+				timer.Stop()
+				timer = nil
+			}
+			deadline = nil
+		}
+		defer stopTimer()
+
+		flush := func() bool {
+			stopTimer()
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-deadline:
+				if !flush() {
+					return
+				}
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					deadline = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Buffer relays in through a channel holding up to size values, decoupling a bursty
+// producer from a slower consumer.
+func Buffer[T any](ctx context.Context, in <-chan T, size int) <-chan T {
+	out := make(chan T, size)
+
+	go func() {
+		defer close(out)
+		for v := range OrDone(ctx, in) {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Bridge flattens a channel of channels into a single channel, draining each inner
+// channel in turn before moving to the next one.
+func Bridge[T any](ctx context.Context, chans <-chan <-chan T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for ch := range OrDone(ctx, chans) {
+			for v := range OrDone(ctx, ch) {
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Stage is a pipeline step reading from one channel and writing to another.
+// It must close its output once its input is closed or ctx is done.
+type Stage[T any] func(ctx context.Context, in <-chan T) <-chan T
+
+// Pipeline chains stages so that the output of each is the input of the next.
+func Pipeline[T any](ctx context.Context, in <-chan T, stages ...Stage[T]) <-chan T {
+	for _, stage := range stages {
+		in = stage(ctx, in)
+	}
+	return in
+}
+
+// MapStage applies iteratee to every value of in on workers goroutines. With more than
+// one worker the output order is not preserved.
+func MapStage[T any, R any](ctx context.Context, in <-chan T, workers int, iteratee func(T) R) <-chan R {
+	out := make(chan R)
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for v := range OrDone(ctx, in) {
+				select {
+				case out <- iteratee(v):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// FilterStage forwards the values of in predicate returns truthy for.
+func FilterStage[T any](ctx context.Context, in <-chan T, predicate func(T) bool) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for v := range OrDone(ctx, in) {
+			if !predicate(v) {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Collect drains in into a slice, returning early with what was received if ctx is done.
+func Collect[T any](ctx context.Context, in <-chan T) []T {
+	result := make([]T, 0)
+	for v := range OrDone(ctx, in) {
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package sugar
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFanInFanOut(t *testing.T) {
+	ctx := context.Background()
+
+	merged := Collect(ctx, FanIn(ctx, Generate(ctx, 1, 2, 3), Generate(ctx, 4, 5)))
+	slices.Sort(merged)
+	if !slices.Equal(merged, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected fan in %v", merged)
+	}
+
+	outs := FanOut(ctx, Generate(ctx, 1, 2, 3, 4), 2, Broadcast)
+	a, b := AsyncRun(func() []int { return Collect(ctx, outs[0]) }), AsyncRun(func() []int { return Collect(ctx, outs[1]) })
+	if got := <-a; !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Fatalf("unexpected broadcast %v", got)
+	}
+	if got := <-b; !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Fatalf("unexpected broadcast %v", got)
+	}
+
+	outs = FanOut(ctx, Generate(ctx, 1, 2), -1, RoundRobin)
+	if len(outs) != 1 {
+		t.Fatalf("expected a negative n to yield one output, got %d", len(outs))
+	}
+	if got := Collect(ctx, outs[0]); !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("unexpected round robin %v", got)
+	}
+}
+
+func TestPipelineStages(t *testing.T) {
+	ctx := context.Background()
+	double := func(ctx context.Context, in <-chan int) <-chan int {
+		return MapStage(ctx, in, 1, func(x int) int { return x * 2 })
+	}
+	even := func(ctx context.Context, in <-chan int) <-chan int {
+		return FilterStage(ctx, in, func(x int) bool { return x%4 == 0 })
+	}
+
+	got := Collect(ctx, Pipeline(ctx, Generate(ctx, Range(0, 6)...), double, even))
+	if !slices.Equal(got, []int{0, 4, 8}) {
+		t.Fatalf("unexpected pipeline output %v", got)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	in := make(chan int)
+	batches := Batch(ctx, in, 2, 10*time.Millisecond)
+
+	go func() {
+		in <- 1
+		in <- 2
+		in <- 3
+	}()
+
+	if got := <-batches; !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("unexpected batch %v", got)
+	}
+	if got := <-batches; !slices.Equal(got, []int{3}) {
+		t.Fatalf("expected partial batch after maxWait, got %v", got)
+	}
+	close(in)
+	if _, ok := <-batches; ok {
+		t.Fatal("expected output to be closed")
+	}
+}
+
+func TestPipelineCancelDoesNotLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	never := make(chan int)
+	out1, out2 := Tee(ctx, Bridge(ctx, Generate[<-chan int](ctx, never)))
+	_ = FanIn(ctx, out1, Buffer(ctx, out2, 4))
+	cancel()
+
+	if !WaitFor(func() bool { return runtime.NumGoroutine() <= before }, time.Second, time.Millisecond) {
+		t.Fatalf("goroutines leaked: %d before, %d after", before, runtime.NumGoroutine())
+	}
+}
@@ -1,23 +1,88 @@
 package sugar
 
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrPrototypeNotFound is returned by PrototypeManager.Get for an unknown name.
+var ErrPrototypeNotFound = errors.New("sugar: prototype not found")
+
 type Cloneable interface {
 	Clone() Cloneable
 }
 
-type PrototypeManager struct {
-	prototypes map[string]Cloneable
+// Cloner is implemented by types knowing how to copy themselves.
+type Cloner[T any] interface {
+	Clone() T
+}
+
+// PrototypeManager is a registry of named prototypes handing out copies of them.
+// Prototypes implementing Cloner[T], such as Cloneable ones in a PrototypeManager[Cloneable],
+// are copied with their Clone method, the others with DeepClone.
+// It is safe for concurrent use.
+type PrototypeManager[T any] struct {
+	mu         sync.RWMutex
+	prototypes map[string]T
 }
 
-func NewPrototypeManager() *PrototypeManager {
-	return &PrototypeManager{
-		prototypes: make(map[string]Cloneable),
+func NewPrototypeManager[T any]() *PrototypeManager[T] {
+	return &PrototypeManager[T]{
+		prototypes: make(map[string]T),
 	}
 }
 
-func (p *PrototypeManager) Get(name string) Cloneable {
-	return p.prototypes[name].Clone()
+// Get returns a copy of the prototype registered as name.
+func (p *PrototypeManager[T]) Get(name string) (T, error) {
+	p.mu.RLock()
+	prototype, ok := p.prototypes[name]
+	p.mu.RUnlock()
+
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%w: %s", ErrPrototypeNotFound, name)
+	}
+	if cloner, ok := any(prototype).(Cloner[T]); ok {
+		return cloner.Clone(), nil
+	}
+	return DeepClone(prototype), nil
 }
 
-func (p *PrototypeManager) Set(name string, prototype Cloneable) {
+// Set registers prototype as name, replacing any previous one.
+func (p *PrototypeManager[T]) Set(name string, prototype T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.prototypes[name] = prototype
 }
+
+// Has reports whether a prototype is registered as name.
+func (p *PrototypeManager[T]) Has(name string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.prototypes[name]
+	return ok
+}
+
+// Remove unregisters the prototype registered as name and reports whether there was one.
+func (p *PrototypeManager[T]) Remove(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.prototypes[name]; !ok {
+		return false
+	}
+	delete(p.prototypes, name)
+	return true
+}
+
+// List returns the registered names in sorted order.
+func (p *PrototypeManager[T]) List() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := Keys(p.prototypes)
+	slices.Sort(names)
+	return names
+}
@@ -1,8 +1,12 @@
 package sugar
 
-import "testing"
+import (
+	"errors"
+	"slices"
+	"testing"
+)
 
-var manager *PrototypeManager
+var manager *PrototypeManager[Cloneable]
 
 type Type1 struct {
 	name string
@@ -23,7 +27,10 @@ func (t *Type2) Clone() Cloneable {
 }
 
 func TestClone(t *testing.T) {
-	t1 := manager.Get("t1")
+	t1, err := manager.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	t2 := t1.Clone()
 
@@ -33,17 +40,47 @@ func TestClone(t *testing.T) {
 }
 
 func TestCloneFromManager(t *testing.T) {
-	c := manager.Get("t1").Clone()
+	c, _ := manager.Get("t1")
 
-	t1 := c.(*Type1)
+	t1 := c.Clone().(*Type1)
 	if t1.name != "type1" {
 		t.Fatal("error")
 	}
 
 }
 
+func TestPrototypeManager(t *testing.T) {
+	if _, err := manager.Get("missing"); !errors.Is(err, ErrPrototypeNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if !manager.Has("t1") || !slices.Equal(manager.List(), []string{"t1"}) {
+		t.Fatalf("unexpected prototypes %v", manager.List())
+	}
+
+	type config struct {
+		Tags  []string
+		Limit *int
+		Self  *config
+	}
+	limit := 3
+	plain := NewPrototypeManager[*config]()
+	prototype := &config{Tags: []string{"a"}, Limit: &limit}
+	prototype.Self = prototype
+	plain.Set("default", prototype)
+
+	clone, _ := plain.Get("default")
+	clone.Tags[0] = "b"
+	*clone.Limit = 4
+	if prototype.Tags[0] != "a" || limit != 3 || clone.Self != clone {
+		t.Fatalf("expected a deep copy keeping the cycle, got %+v", clone)
+	}
+	if !plain.Remove("default") || plain.Has("default") {
+		t.Fatal("expected prototype to be removed")
+	}
+}
+
 func init() {
-	manager = NewPrototypeManager()
+	manager = NewPrototypeManager[Cloneable]()
 
 	t1 := &Type1{
 		name: "type1",
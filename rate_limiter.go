@@ -0,0 +1,540 @@
+package sugar
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by Wait when a limiter cannot admit the event at all,
+// e.g. a full leaky bucket, or when the required delay exceeds the context deadline.
+var ErrRateLimited = errors.New("sugar: rate limit exceeded")
+
+// RateLimiter admits events at a bounded rate.
+type RateLimiter interface {
+	// Allow reports whether an event may happen now, consuming capacity if so.
+	Allow() bool
+	// Reserve claims capacity for one event and tells how long to wait before acting on it.
+	Reserve() *Reservation
+	// Wait blocks until an event may happen or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// Reservation is capacity claimed by RateLimiter.Reserve.
+type Reservation struct {
+	ok     bool
+	delay  time.Duration
+	cancel func()
+	once   sync.Once
+}
+
+// OK reports whether the limiter can ever admit the event. When false Delay is meaningless.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns how long to wait before the reserved event may happen.
+func (r *Reservation) Delay() time.Duration {
+	return r.delay
+}
+
+// Cancel gives the reserved capacity back to the limiter, as far as it can.
+func (r *Reservation) Cancel() {
+	if !r.ok || r.cancel == nil {
+		return
+	}
+	r.once.Do(r.cancel)
+}
+
+// RateLimiterOption configures a RateLimiter.
+type RateLimiterOption func(*rateLimiterConfig)
+
+type rateLimiterConfig struct {
+	clock Clock
+}
+
+func newRateLimiterConfig(opts []RateLimiterOption) rateLimiterConfig {
+	config := rateLimiterConfig{clock: SystemClock()}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// RateLimiterClock sets the Clock used by the limiter.
+func RateLimiterClock(clock Clock) RateLimiterOption {
+	return func(c *rateLimiterConfig) {
+		c.clock = clock
+	}
+}
+
+func waitReservation(ctx context.Context, clock Clock, r *Reservation) error {
+	if !r.OK() {
+		return ErrRateLimited
+	}
+	if deadline, ok := ctx.Deadline(); ok && clock.Now().Add(r.Delay()).After(deadline) {
+		r.Cancel()
+		return ErrRateLimited
+	}
+	if err := sleepContext(ctx, clock, r.Delay()); err != nil {
+		r.Cancel()
+		return err
+	}
+	return nil
+}
+
+// TokenBucket refills rate tokens per second up to burst; every event consumes one token.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+	clock Clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full TokenBucket refilling rate tokens per second and holding at most burst.
+func NewTokenBucket(rate float64, burst int, opts ...RateLimiterOption) *TokenBucket {
+	config := newRateLimiterConfig(opts)
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		clock:  config.clock,
+		tokens: float64(burst),
+		last:   config.clock.Now(),
+	}
+}
+
+// refill must be called with the lock held.
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// Tokens returns the number of tokens currently available.
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	return b.tokens
+}
+
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(b.clock.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *TokenBucket) Reserve() *Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.burst < 1 || b.rate <= 0 {
+		return &Reservation{}
+	}
+
+	b.refill(b.clock.Now())
+	b.tokens--
+
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+
+	return &Reservation{ok: true, delay: delay, cancel: func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.refill(b.clock.Now())
+		b.tokens = min(b.burst, b.tokens+1)
+	}}
+}
+
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return waitReservation(ctx, b.clock, b.Reserve())
+}
+
+// LeakyBucket lets events out at a constant pace of one per interval, queueing at most
+// capacity events; events that would overflow the queue are rejected.
+type LeakyBucket struct {
+	interval time.Duration
+	capacity int
+	clock    Clock
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewLeakyBucket returns an empty LeakyBucket emitting one event per interval with room for capacity waiting events.
+func NewLeakyBucket(interval time.Duration, capacity int, opts ...RateLimiterOption) *LeakyBucket {
+	config := newRateLimiterConfig(opts)
+	return &LeakyBucket{
+		interval: interval,
+		capacity: capacity,
+		clock:    config.clock,
+	}
+}
+
+func (b *LeakyBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if b.next.After(now) {
+		return false
+	}
+	b.next = now.Add(b.interval)
+	return true
+}
+
+func (b *LeakyBucket) Reserve() *Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	slot := now
+	if b.next.After(now) {
+		slot = b.next
+	}
+	delay := slot.Sub(now)
+	if delay > time.Duration(b.capacity)*b.interval {
+		return &Reservation{}
+	}
+
+	b.next = slot.Add(b.interval)
+	end := b.next
+	return &Reservation{ok: true, delay: delay, cancel: func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// Only the most recent reservation can be handed back without reordering the queue.
+		if b.next.Equal(end) {
+			b.next = end.Add(-b.interval)
+		}
+	}}
+}
+
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	return waitReservation(ctx, b.clock, b.Reserve())
+}
+
+// FixedWindow admits at most limit events per window, with windows aligned to multiples of window.
+type FixedWindow struct {
+	limit  int
+	window time.Duration
+	clock  Clock
+
+	mu     sync.Mutex
+	counts map[int64]int
+}
+
+// NewFixedWindow returns a FixedWindow admitting limit events per window.
+// It panics if window is not positive.
+func NewFixedWindow(limit int, window time.Duration, opts ...RateLimiterOption) *FixedWindow {
+	if window <= 0 {
+		panic("sugar: FixedWindow window must be positive")
+	}
+	config := newRateLimiterConfig(opts)
+	return &FixedWindow{
+		limit:  limit,
+		window: window,
+		clock:  config.clock,
+		counts: make(map[int64]int),
+	}
+}
+
+// current must be called with the lock held. It also drops past windows.
+func (w *FixedWindow) current(now time.Time) int64 {
+	index := now.UnixNano() / int64(w.window)
+	for k := range w.counts {
+		if k < index {
+			delete(w.counts, k)
+		}
+	}
+	return index
+}
+
+func (w *FixedWindow) Allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	index := w.current(w.clock.Now())
+	if w.counts[index] >= w.limit {
+		return false
+	}
+	w.counts[index]++
+	return true
+}
+
+func (w *FixedWindow) Reserve() *Reservation {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.limit <= 0 {
+		return &Reservation{}
+	}
+
+	now := w.clock.Now()
+	index := w.current(now)
+	for w.counts[index] >= w.limit {
+		index++
+	}
+	w.counts[index]++
+
+	var delay time.Duration
+	if start := time.Unix(0, index*int64(w.window)); start.After(now) {
+		delay = start.Sub(now)
+	}
+
+	return &Reservation{ok: true, delay: delay, cancel: func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.counts[index] > 0 {
+			w.counts[index]--
+		}
+	}}
+}
+
+func (w *FixedWindow) Wait(ctx context.Context) error {
+	return waitReservation(ctx, w.clock, w.Reserve())
+}
+
+// SlidingWindow admits at most limit events within any window-long span of time.
+// It keeps the timestamp of every admitted event of the last window.
+type SlidingWindow struct {
+	limit  int
+	window time.Duration
+	clock  Clock
+
+	mu  sync.Mutex
+	log []time.Time
+}
+
+// NewSlidingWindow returns a SlidingWindow admitting limit events per sliding window.
+func NewSlidingWindow(limit int, window time.Duration, opts ...RateLimiterOption) *SlidingWindow {
+	config := newRateLimiterConfig(opts)
+	return &SlidingWindow{
+		limit:  limit,
+		window: window,
+		clock:  config.clock,
+	}
+}
+
+// prune must be called with the lock held.
+func (w *SlidingWindow) prune(now time.Time) {
+	cutoff := now.Add(-w.window)
+	i := 0
+	for i < len(w.log) && !w.log[i].After(cutoff) {
+		i++
+	}
+	w.log = w.log[i:]
+}
+
+func (w *SlidingWindow) Allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.clock.Now()
+	w.prune(now)
+	if len(w.log) >= w.limit || (len(w.log) > 0 && w.log[len(w.log)-1].After(now)) {
+		return false
+	}
+	w.log = append(w.log, now)
+	return true
+}
+
+func (w *SlidingWindow) Reserve() *Reservation {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.limit <= 0 || w.window <= 0 {
+		return &Reservation{}
+	}
+
+	now := w.clock.Now()
+	w.prune(now)
+
+	at := now
+	if n := len(w.log); n > 0 {
+		if n >= w.limit {
+			if free := w.log[n-w.limit].Add(w.window); free.After(at) {
+				at = free
+			}
+		}
+		if last := w.log[n-1]; last.After(at) {
+			at = last
+		}
+	}
+	w.log = append(w.log, at)
+
+	return &Reservation{ok: true, delay: at.Sub(now), cancel: func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for i := len(w.log) - 1; i >= 0; i-- {
+			if w.log[i].Equal(at) {
+				w.log = append(w.log[:i], w.log[i+1:]...)
+				return
+			}
+		}
+	}}
+}
+
+func (w *SlidingWindow) Wait(ctx context.Context) error {
+	return waitReservation(ctx, w.clock, w.Reserve())
+}
+
+// RateLimiterRegistry hands out one RateLimiter per key, e.g. per client or per host,
+// and forgets limiters that have not been used for the idle duration.
+type RateLimiterRegistry[K comparable] struct {
+	factory func(K) RateLimiter
+	idle    time.Duration
+	clock   Clock
+
+	mu        sync.Mutex
+	limiters  map[K]*registryEntry
+	lastSweep time.Time
+}
+
+type registryEntry struct {
+	limiter  RateLimiter
+	lastUsed time.Time
+}
+
+// NewRateLimiterRegistry returns a registry creating limiters with factory on first use of a key.
+// When idle is greater than 0, limiters unused for that long are evicted.
+func NewRateLimiterRegistry[K comparable](factory func(K) RateLimiter, idle time.Duration, opts ...RateLimiterOption) *RateLimiterRegistry[K] {
+	config := newRateLimiterConfig(opts)
+	return &RateLimiterRegistry[K]{
+		factory:   factory,
+		idle:      idle,
+		clock:     config.clock,
+		limiters:  make(map[K]*registryEntry),
+		lastSweep: config.clock.Now(),
+	}
+}
+
+// Get returns the limiter for key, creating it if needed.
+func (r *RateLimiterRegistry[K]) Get(key K) RateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	if r.idle > 0 && now.Sub(r.lastSweep) >= r.idle {
+		r.sweep(now)
+	}
+
+	entry, ok := r.limiters[key]
+	if !ok {
+		entry = &registryEntry{limiter: r.factory(key)}
+		r.limiters[key] = entry
+	}
+	entry.lastUsed = now
+	return entry.limiter
+}
+
+// Allow is shorthand for Get(key).Allow().
+func (r *RateLimiterRegistry[K]) Allow(key K) bool {
+	return r.Get(key).Allow()
+}
+
+// Wait is shorthand for Get(key).Wait(ctx).
+func (r *RateLimiterRegistry[K]) Wait(ctx context.Context, key K) error {
+	return r.Get(key).Wait(ctx)
+}
+
+// Remove forgets the limiter for key.
+func (r *RateLimiterRegistry[K]) Remove(key K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.limiters, key)
+}
+
+// Len returns the number of keys currently tracked.
+func (r *RateLimiterRegistry[K]) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.limiters)
+}
+
+// EvictIdle drops every limiter unused for the idle duration. It runs automatically from Get.
+func (r *RateLimiterRegistry[K]) EvictIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweep(r.clock.Now())
+}
+
+// sweep must be called with the lock held.
+func (r *RateLimiterRegistry[K]) sweep(now time.Time) {
+	r.lastSweep = now
+	if r.idle <= 0 {
+		return
+	}
+	for key, entry := range r.limiters {
+		if now.Sub(entry.lastUsed) >= r.idle {
+			delete(r.limiters, key)
+		}
+	}
+}
+
+// RateLimitFunc returns a function that, like Throttle, limits how often callback runs,
+// but queues calls instead of dropping them: every call is invoked in order, on a background
+// goroutine, as soon as limiter admits it. Queued calls are abandoned once ctx is done;
+// a call the limiter refuses outright, such as one overflowing a LeakyBucket, is skipped.
+// The background goroutine only exits once ctx is done, so the caller must cancel ctx when
+// the function is no longer needed.
+func RateLimitFunc[T any](ctx context.Context, limiter RateLimiter, callback func(...T)) func(...T) {
+	var (
+		mu     sync.Mutex
+		queue  [][]T
+		notify = make(chan struct{}, 1)
+	)
+
+	go func() {
+		for {
+			mu.Lock()
+			if len(queue) == 0 {
+				mu.Unlock()
+				select {
+				case <-ctx.Done():
+					return
+				case <-notify:
+					continue
+				}
+			}
+			args := queue[0]
+			queue = queue[1:]
+			mu.Unlock()
+
+			if err := limiter.Wait(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				continue
+			}
+			callback(args...)
+		}
+	}()
+
+	return func(args ...T) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		mu.Lock()
+		queue = append(queue, args)
+		mu.Unlock()
+
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+}
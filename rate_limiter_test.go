@@ -0,0 +1,119 @@
+package sugar
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	bucket := NewTokenBucket(10, 2, RateLimiterClock(clock))
+
+	if !bucket.Allow() || !bucket.Allow() || bucket.Allow() {
+		t.Fatal("expected burst of 2")
+	}
+
+	r := bucket.Reserve()
+	if !r.OK() || r.Delay() != 100*time.Millisecond {
+		t.Fatalf("unexpected reservation delay %v", r.Delay())
+	}
+	r.Cancel()
+
+	clock.Advance(100 * time.Millisecond)
+	if !bucket.Allow() {
+		t.Fatal("expected a token after refill")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	window := NewSlidingWindow(2, time.Second, RateLimiterClock(clock))
+
+	window.Allow()
+	clock.Advance(600 * time.Millisecond)
+	window.Allow()
+	if window.Allow() {
+		t.Fatal("expected limit to be reached")
+	}
+
+	if r := window.Reserve(); r.Delay() != 400*time.Millisecond {
+		t.Fatalf("unexpected delay %v", r.Delay())
+	}
+}
+
+func TestFixedWindowAndLeakyBucket(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	fixed := NewFixedWindow(1, time.Second, RateLimiterClock(clock))
+	fixed.Allow()
+	clock.Advance(300 * time.Millisecond)
+	if r := fixed.Reserve(); r.Delay() != 700*time.Millisecond {
+		t.Fatalf("unexpected delay %v", r.Delay())
+	}
+
+	leaky := NewLeakyBucket(100*time.Millisecond, 1, RateLimiterClock(clock))
+	if r := leaky.Reserve(); r.Delay() != 0 {
+		t.Fatalf("unexpected delay %v", r.Delay())
+	}
+	if r := leaky.Reserve(); !r.OK() || r.Delay() != 100*time.Millisecond {
+		t.Fatalf("unexpected delay %v", r.Delay())
+	}
+	if leaky.Reserve().OK() {
+		t.Fatal("expected full bucket to reject")
+	}
+}
+
+func TestFixedWindowRejectsNonPositiveWindow(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "sugar: FixedWindow window must be positive" {
+			t.Fatalf("expected a non-positive window to panic, got %v", r)
+		}
+	}()
+
+	NewFixedWindow(1, 0)
+}
+
+func TestRateLimiterRegistry(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	registry := NewRateLimiterRegistry(func(string) RateLimiter {
+		return NewTokenBucket(1, 1, RateLimiterClock(clock))
+	}, time.Minute, RateLimiterClock(clock))
+
+	if !registry.Allow("a") || registry.Allow("a") || !registry.Allow("b") {
+		t.Fatal("expected independent limiters per key")
+	}
+
+	clock.Advance(time.Minute)
+	registry.Allow("c")
+	if registry.Len() != 1 {
+		t.Fatalf("expected idle limiters to be evicted, got %d", registry.Len())
+	}
+}
+
+func TestRateLimitFuncQueues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var got []int
+	call := RateLimitFunc(ctx, NewTokenBucket(1000, 1), func(args ...int) {
+		mu.Lock()
+		got = append(got, args...)
+		mu.Unlock()
+		wg.Done()
+	})
+
+	wg.Add(5)
+	for i := 0; i < 5; i++ {
+		call(i)
+	}
+	wg.Wait()
+
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("expected calls in order, got %v", got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package sugar
+
+import (
+	"fmt"
+	"time"
+)
+
+// PanicError wraps a value recovered from a panic so it can travel as an error.
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the recovered value when it is itself an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Result holds either a value of type T or an error.
+// The zero Result is Ok with the zero value of T.
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+// Ok returns a successful Result holding value.
+func Ok[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+// Err returns a failed Result holding err.
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// ResultFrom converts a (T, error) pair into a Result. It composes with any function
+// returning (T, error): ResultFrom(strconv.Atoi(s)).
+func ResultFrom[T any](value T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(value)
+}
+
+// TryResult calls the function and captures its value, error or panic in a Result.
+// A panic is reported as a *PanicError.
+func TryResult[T any](callback func() (T, error)) (result Result[T]) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = Err[T](&PanicError{Value: r})
+		}
+	}()
+
+	return ResultFrom(callback())
+}
+
+// IsOk returns true if the result holds a value.
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// IsErr returns true if the result holds an error.
+func (r Result[T]) IsErr() bool {
+	return r.err != nil
+}
+
+// Error returns the held error, or nil.
+func (r Result[T]) Error() error {
+	return r.err
+}
+
+// Get returns the result as a (T, error) pair.
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+// Unwrap returns the value, panicking with the held error if there is one.
+func (r Result[T]) Unwrap() T {
+	return Must(r.value, r.err)
+}
+
+// UnwrapOr returns the value, or fallback if the result holds an error.
+func (r Result[T]) UnwrapOr(fallback T) T {
+	if r.err != nil {
+		return fallback
+	}
+	return r.value
+}
+
+// UnwrapOrElse returns the value, or the result of callback if the result holds an error.
+func (r Result[T]) UnwrapOrElse(callback func(error) T) T {
+	if r.err != nil {
+		return callback(r.err)
+	}
+	return r.value
+}
+
+// Map transforms the held value, leaving an error untouched.
+// Use ResultMap to change the value type.
+func (r Result[T]) Map(iteratee func(T) T) Result[T] {
+	return ResultMap(r, iteratee)
+}
+
+// FlatMap chains a fallible operation on the held value, leaving an error untouched.
+// Use ResultFlatMap to change the value type.
+func (r Result[T]) FlatMap(iteratee func(T) Result[T]) Result[T] {
+	return ResultFlatMap(r, iteratee)
+}
+
+// OrElse returns r if it is ok, otherwise the result of callback applied to the held error.
+func (r Result[T]) OrElse(callback func(error) Result[T]) Result[T] {
+	if r.err != nil {
+		return callback(r.err)
+	}
+	return r
+}
+
+// ResultMap transforms the value of r into another type, leaving an error untouched.
+func ResultMap[T any, R any](r Result[T], iteratee func(T) R) Result[R] {
+	if r.err != nil {
+		return Err[R](r.err)
+	}
+	return Ok(iteratee(r.value))
+}
+
+// ResultFlatMap chains a fallible operation producing another type, leaving an error untouched.
+func ResultFlatMap[T any, R any](r Result[T], iteratee func(T) Result[R]) Result[R] {
+	if r.err != nil {
+		return Err[R](r.err)
+	}
+	return iteratee(r.value)
+}
+
+// CollectResults turns a slice of results into a result of slice, failing on the first error.
+func CollectResults[T any](results []Result[T]) Result[[]T] {
+	values := make([]T, len(results))
+
+	for i, r := range results {
+		if r.err != nil {
+			return Err[[]T](&IndexError{Index: i, Err: r.err})
+		}
+		values[i] = r.value
+	}
+
+	return Ok(values)
+}
+
+// MapResult is like Map but captures the outcome of every iteratee call, including panics, in a Result.
+func MapResult[T any, R any](collection []T, iteratee func(T, int) (R, error)) []Result[R] {
+	result := make([]Result[R], len(collection))
+
+	for i, item := range collection {
+		result[i] = TryResult(func() (R, error) {
+			return iteratee(item, i)
+		})
+	}
+
+	return result
+}
+
+// AsyncResult is like AsyncErr but delivers the value and error together on a single channel.
+// A panic in callback is delivered as a *PanicError.
+func AsyncResult[T any](callback func() (T, error)) <-chan Result[T] {
+	ch := make(chan Result[T], 1)
+
+	go func() {
+		defer close(ch)
+		ch <- TryResult(callback)
+	}()
+
+	return ch
+}
+
+// AttemptWithDelayResult is like AttemptWithDelay but returns the value of the first
+// successful attempt, or the last error, as a Result.
+func AttemptWithDelayResult[T any](maxIteration int, delay time.Duration, f func(int, time.Duration) (T, error)) Result[T] {
+	var value T
+
+	_, _, err := AttemptWithDelay(maxIteration, delay, func(i int, elapsed time.Duration) error {
+		var err error
+		value, err = f(i, elapsed)
+		return err
+	})
+
+	return ResultFrom(value, err)
+}
@@ -0,0 +1,37 @@
+package sugar
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	r := ResultMap(ResultFrom(strconv.Atoi("21")), func(x int) string {
+		return strconv.Itoa(x * 2)
+	})
+	if r.Unwrap() != "42" {
+		t.Fatalf("unexpected value %v", r.Unwrap())
+	}
+
+	failed := ResultFrom(strconv.Atoi("x")).Map(func(x int) int { return x + 1 })
+	if failed.IsOk() || failed.UnwrapOr(-1) != -1 {
+		t.Fatal("expected error to be kept")
+	}
+
+	recovered := failed.OrElse(func(error) Result[int] { return Ok(7) })
+	if recovered.Unwrap() != 7 {
+		t.Fatal("expected OrElse to recover")
+	}
+}
+
+func TestAsyncResultPanic(t *testing.T) {
+	r := <-AsyncResult(func() (int, error) {
+		panic("boom")
+	})
+
+	var panicErr *PanicError
+	if !errors.As(r.Error(), &panicErr) || panicErr.Value != "boom" {
+		t.Fatalf("expected captured panic, got %v", r.Error())
+	}
+}
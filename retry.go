@@ -1,74 +1,280 @@
 package sugar
 
 import (
-	"sync"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
 	"time"
 )
 
-type Debounce struct {
-	after     time.Duration
-	mu        *sync.Mutex
-	timer     *time.Timer
-	done      bool
-	callbacks []func()
+// Backoff computes the delay to wait after the given zero-based failed attempt.
+// previous is the delay returned for the prior attempt, or 0 for the first one.
+type Backoff func(attempt int, previous time.Duration) time.Duration
+
+// ConstantBackoff waits the same delay between every attempt.
+func ConstantBackoff(delay time.Duration) Backoff {
+	return func(int, time.Duration) time.Duration {
+		return delay
+	}
+}
+
+// LinearBackoff waits initial, initial+step, initial+2*step, ... capped at max when max > 0.
+func LinearBackoff(initial, step, max time.Duration) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		return capDelay(initial+time.Duration(attempt)*step, max)
+	}
+}
+
+// ExponentialBackoff waits initial, initial*factor, initial*factor^2, ... capped at max when max > 0.
+func ExponentialBackoff(initial time.Duration, factor float64, max time.Duration) Backoff {
+	return func(attempt int, previous time.Duration) time.Duration {
+		if attempt == 0 || previous <= 0 {
+			return capDelay(initial, max)
+		}
+		next := float64(previous) * factor
+		if next >= math.MaxInt64 {
+			return capDelay(math.MaxInt64, max)
+		}
+		return capDelay(time.Duration(next), max)
+	}
+}
+
+// DecorrelatedJitterBackoff waits a random delay between base and three times the previous delay,
+// capped at max when max > 0, which spreads out retries from many concurrent clients.
+func DecorrelatedJitterBackoff(base, max time.Duration) Backoff {
+	return func(_ int, previous time.Duration) time.Duration {
+		if previous < base {
+			previous = base
+		}
+		upper := time.Duration(math.MaxInt64)
+		if previous <= math.MaxInt64/3 {
+			upper = previous * 3
+		}
+		n := upper - base
+		if n < math.MaxInt64 {
+			n++
+		}
+		if n <= 0 {
+			n = 1
+		}
+		return capDelay(base+rand.N(n), max)
+	}
+}
+
+// capDelay caps delay at max when max > 0. A negative delay is an overflow and saturates.
+func capDelay(delay, max time.Duration) time.Duration {
+	if delay < 0 {
+		delay = math.MaxInt64
+	}
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
+
+type unrecoverableError struct {
+	err error
+}
+
+func (e *unrecoverableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unrecoverableError) Unwrap() error {
+	return e.err
+}
+
+// Unrecoverable marks err so that Retry gives up immediately instead of retrying.
+func Unrecoverable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &unrecoverableError{err}
+}
+
+// IsUnrecoverable reports whether err was marked with Unrecoverable.
+func IsUnrecoverable(err error) bool {
+	var target *unrecoverableError
+	return errors.As(err, &target)
+}
+
+// RetryOption configures Retry and RetryValue.
+type RetryOption func(*retryConfig)
+
+type retryConfig struct {
+	maxAttempts    int
+	backoff        Backoff
+	maxElapsed     time.Duration
+	attemptTimeout time.Duration
+	retryIf        func(error) bool
+	onRetry        []func(attempt int, err error, delay time.Duration)
+	clock          Clock
+}
+
+func newRetryConfig(opts []RetryOption) *retryConfig {
+	c := &retryConfig{
+		maxAttempts: 3,
+		backoff:     ExponentialBackoff(100*time.Millisecond, 2, 10*time.Second),
+		retryIf:     func(error) bool { return true },
+		clock:       SystemClock(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RetryAttempts sets the maximum number of attempts. When n is less than 1
+// Retry keeps going until it succeeds or the context is done. Defaults to 3.
+func RetryAttempts(n int) RetryOption {
+	return func(c *retryConfig) {
+		c.maxAttempts = n
+	}
+}
+
+// RetryBackoff sets the delay policy between attempts. Defaults to
+// ExponentialBackoff(100ms, 2, 10s).
+func RetryBackoff(backoff Backoff) RetryOption {
+	return func(c *retryConfig) {
+		c.backoff = backoff
+	}
 }
 
-func NewDebounce(duration time.Duration, fns ...func()) (func(), func()) {
-	d := &Debounce{
-		after:     duration,
-		mu:        new(sync.Mutex),
-		timer:     nil,
-		done:      false,
-		callbacks: fns,
+// RetryMaxElapsed stops retrying once the next attempt would start later than d after the first one.
+func RetryMaxElapsed(d time.Duration) RetryOption {
+	return func(c *retryConfig) {
+		c.maxElapsed = d
 	}
-	return func() {
-		d.reset()
-	}, d.cancel
 }
 
-func (d *Debounce) reset() *Debounce {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	if d.done {
-		return d
+// RetryAttemptTimeout bounds every attempt with its own context deadline.
+func RetryAttemptTimeout(d time.Duration) RetryOption {
+	return func(c *retryConfig) {
+		c.attemptTimeout = d
 	}
-	if d.timer != nil {
-		d.timer.Stop()
+}
+
+// RetryIf sets the classifier deciding whether an error is worth retrying.
+// Errors marked with Unrecoverable are never retried regardless of the classifier.
+func RetryIf(retryable func(error) bool) RetryOption {
+	return func(c *retryConfig) {
+		c.retryIf = retryable
 	}
-	d.timer = time.AfterFunc(d.after, func() {
-		for _, cb := range d.callbacks {
-			cb()
+}
+
+// OnRetry registers a hook called after every failed attempt that will be retried,
+// with the zero-based attempt, its error and the delay before the next attempt.
+func OnRetry(hook func(attempt int, err error, delay time.Duration)) RetryOption {
+	return func(c *retryConfig) {
+		c.onRetry = append(c.onRetry, hook)
+	}
+}
+
+// RetryClock sets the Clock used to measure elapsed time and wait between attempts.
+func RetryClock(clock Clock) RetryOption {
+	return func(c *retryConfig) {
+		c.clock = clock
+	}
+}
+
+// Retry calls f until it succeeds, the attempts are exhausted, the error is not retryable
+// or ctx is done, waiting between attempts according to the configured Backoff.
+// It returns nil on success, otherwise the last error of f; if ctx ends the wait
+// the context error is returned wrapping the last error as well.
+func Retry(ctx context.Context, f func(ctx context.Context, attempt int) error, opts ...RetryOption) error {
+	_, err := RetryValue(ctx, func(ctx context.Context, attempt int) (struct{}, error) {
+		return struct{}{}, f(ctx, attempt)
+	}, opts...)
+	return err
+}
+
+// RetryValue is like Retry but returns the value of the successful attempt.
+func RetryValue[T any](ctx context.Context, f func(ctx context.Context, attempt int) (T, error), opts ...RetryOption) (T, error) {
+	c := newRetryConfig(opts)
+	start := c.clock.Now()
+
+	var (
+		zero  T
+		delay time.Duration
+	)
+
+	for attempt := 0; ; attempt++ {
+		value, err := retryAttempt(ctx, c, f, attempt)
+		if err == nil {
+			return value, nil
 		}
-	})
-	return d
+
+		if ctx.Err() != nil {
+			return zero, fmt.Errorf("%w: %w", context.Cause(ctx), err)
+		}
+		if IsUnrecoverable(err) || !c.retryIf(err) {
+			return zero, err
+		}
+		if c.maxAttempts > 0 && attempt+1 >= c.maxAttempts {
+			return zero, err
+		}
+
+		delay = c.backoff(attempt, delay)
+		if c.maxElapsed > 0 && c.clock.Now().Sub(start)+delay > c.maxElapsed {
+			return zero, err
+		}
+
+		for _, hook := range c.onRetry {
+			hook(attempt, err, delay)
+		}
+
+		if werr := sleepContext(ctx, c.clock, delay); werr != nil {
+			return zero, fmt.Errorf("%w: %w", werr, err)
+		}
+	}
 }
 
-func (d *Debounce) cancel() {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	if d.timer != nil {
-		d.timer.Stop()
-		d.timer = nil
+func retryAttempt[T any](ctx context.Context, c *retryConfig, f func(context.Context, int) (T, error), attempt int) (T, error) {
+	if c.attemptTimeout <= 0 {
+		return f(ctx, attempt)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, c.attemptTimeout)
+	defer cancel()
+
+	return f(attemptCtx, attempt)
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	wake := make(chan struct{})
+	timer := clock.AfterFunc(d, func() { close(wake) })
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-wake:
+		return nil
 	}
-	d.done = true
 }
 
 // AttemptWithDelay invokes a function N times until it returns valid output,
 // with a pause between each call. Returning either the caught error or nil.
 // When first argument is less than `1`, the function runs until a successful
 // response is returned.
-
+//
+// It is a thin wrapper around Retry with a ConstantBackoff; use Retry directly
+// for context cancellation, other backoff policies and hooks.
 func AttemptWithDelay(maxIteration int, delay time.Duration, f func(int, time.Duration) error) (int, time.Duration, error) {
-	var err error
 	start := time.Now()
-	for i := 0; maxIteration <= 0 || i < maxIteration; i++ {
-		err = f(i, time.Since(start))
-		if err == nil {
-			return i + 1, time.Since(start), nil
-		}
-		if maxIteration <= 0 || i+1 < maxIteration {
-			time.Sleep(delay)
-		}
-	}
-	return maxIteration, time.Since(start), err
+	attempts := 0
+
+	err := Retry(context.Background(), func(_ context.Context, attempt int) error {
+		attempts = attempt + 1
+		return f(attempt, time.Since(start))
+	}, RetryAttempts(maxIteration), RetryBackoff(ConstantBackoff(delay)))
+
+	return attempts, time.Since(start), err
 }
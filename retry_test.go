@@ -0,0 +1,100 @@
+package sugar
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 2, 50*time.Millisecond)
+
+	var delay time.Duration
+	var got []time.Duration
+	for attempt := 0; attempt < 4; attempt++ {
+		delay = backoff(attempt, delay)
+		got = append(got, delay)
+	}
+
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected delays %v", got)
+		}
+	}
+}
+
+func TestBackoffSaturates(t *testing.T) {
+	exponential := ExponentialBackoff(time.Second, 10, 0)
+	var delay time.Duration
+	for attempt := 0; attempt < 200; attempt++ {
+		next := exponential(attempt, delay)
+		if next < delay {
+			t.Fatalf("delay went from %v to %v at attempt %d", delay, next, attempt)
+		}
+		delay = next
+	}
+	if delay != math.MaxInt64 {
+		t.Fatalf("expected the delay to saturate, got %v", delay)
+	}
+
+	jitter := DecorrelatedJitterBackoff(time.Second, 0)
+	for attempt, delay := 0, time.Duration(math.MaxInt64); attempt < 10000; attempt++ {
+		if delay = jitter(attempt, delay); delay < time.Second {
+			t.Fatalf("unexpected delay %v at attempt %d", delay, attempt)
+		}
+		if attempt%100 == 0 {
+			delay = math.MaxInt64
+		}
+	}
+}
+
+func TestRetryValue(t *testing.T) {
+	retries := 0
+	value, err := RetryValue(context.Background(), func(_ context.Context, attempt int) (int, error) {
+		if attempt < 2 {
+			return 0, errors.New("not yet")
+		}
+		return attempt, nil
+	}, RetryAttempts(5), RetryBackoff(ConstantBackoff(time.Millisecond)), OnRetry(func(int, error, time.Duration) {
+		retries++
+	}))
+	if err != nil || value != 2 || retries != 2 {
+		t.Fatalf("unexpected result %d, %v after %d retries", value, err, retries)
+	}
+}
+
+func TestRetryUnrecoverable(t *testing.T) {
+	boom := errors.New("boom")
+	calls := 0
+	err := Retry(context.Background(), func(context.Context, int) error {
+		calls++
+		return Unrecoverable(boom)
+	}, RetryAttempts(5), RetryBackoff(ConstantBackoff(time.Millisecond)))
+	if !errors.Is(err, boom) || calls != 1 {
+		t.Fatalf("expected a single call, got %d calls and %v", calls, err)
+	}
+}
+
+func TestRetryContextCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := Retry(ctx, func(context.Context, int) error {
+		return errors.New("down")
+	}, RetryAttempts(0), RetryBackoff(ConstantBackoff(time.Hour)))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestAttemptWithDelay(t *testing.T) {
+	attempts, _, err := AttemptWithDelay(3, time.Millisecond, func(int, time.Duration) error {
+		return errors.New("always")
+	})
+	if attempts != 3 || err == nil {
+		t.Fatalf("unexpected attempts %d, %v", attempts, err)
+	}
+}
@@ -1,22 +1,250 @@
 package sugar
 
-type RingBuffer struct {
-	inputChannel  <-chan any
-	outputChannel chan any
+import (
+	"iter"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what a full RingBuffer does with a new value.
+type OverflowPolicy int
+
+const (
+	// Overwrite evicts the oldest value to make room for the new one.
+	Overwrite OverflowPolicy = iota
+	// Reject keeps the buffer as is and refuses the new value.
+	Reject
+)
+
+// RingBuffer is a fixed-capacity FIFO queue backed by a circular slice.
+// It is not safe for concurrent use; see SPSCRingBuffer for a lock-free variant.
+type RingBuffer[T any] struct {
+	buf    []T
+	head   int
+	size   int
+	policy OverflowPolicy
+}
+
+// NewRingBuffer returns an empty RingBuffer holding at most capacity values.
+func NewRingBuffer[T any](capacity int, policy OverflowPolicy) *RingBuffer[T] {
+	if capacity <= 0 {
+		panic("sugar: ring buffer capacity must be positive")
+	}
+	return &RingBuffer[T]{
+		buf:    make([]T, capacity),
+		policy: policy,
+	}
+}
+
+// Push appends v at the back. It returns false if the buffer is full and the policy is Reject.
+func (r *RingBuffer[T]) Push(v T) bool {
+	_, _, ok := r.PushEvict(v)
+	return ok
+}
+
+// PushEvict is like Push but also returns the value evicted to make room for v, if any.
+func (r *RingBuffer[T]) PushEvict(v T) (evicted T, didEvict bool, ok bool) {
+	if r.size == len(r.buf) {
+		if r.policy == Reject {
+			return evicted, false, false
+		}
+		evicted, _ = r.Pop()
+		didEvict = true
+	}
+
+	r.buf[(r.head+r.size)%len(r.buf)] = v
+	r.size++
+	return evicted, didEvict, true
+}
+
+// Pop removes and returns the value at the front.
+func (r *RingBuffer[T]) Pop() (T, bool) {
+	var zero T
+	if r.size == 0 {
+		return zero, false
+	}
+
+	v := r.buf[r.head]
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.size--
+	return v, true
+}
+
+// PeekFront returns the oldest value without removing it.
+func (r *RingBuffer[T]) PeekFront() (T, bool) {
+	return r.At(0)
+}
+
+// PeekBack returns the newest value without removing it.
+func (r *RingBuffer[T]) PeekBack() (T, bool) {
+	return r.At(r.size - 1)
+}
+
+// At returns the i-th value counted from the front.
+func (r *RingBuffer[T]) At(i int) (T, bool) {
+	if i < 0 || i >= r.size {
+		var zero T
+		return zero, false
+	}
+	return r.buf[(r.head+i)%len(r.buf)], true
+}
+
+// Len returns the number of values held.
+func (r *RingBuffer[T]) Len() int {
+	return r.size
+}
+
+// Cap returns the maximum number of values the buffer can hold.
+func (r *RingBuffer[T]) Cap() int {
+	return len(r.buf)
+}
+
+// IsFull returns true if Len equals Cap.
+func (r *RingBuffer[T]) IsFull() bool {
+	return r.size == len(r.buf)
+}
+
+// IsEmpty returns true if the buffer holds no value.
+func (r *RingBuffer[T]) IsEmpty() bool {
+	return r.size == 0
+}
+
+// Clear removes every value.
+func (r *RingBuffer[T]) Clear() {
+	clear(r.buf)
+	r.head = 0
+	r.size = 0
+}
+
+// All returns an iterator over the positions and values from front to back.
+func (r *RingBuffer[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := 0; i < r.size; i++ {
+			if !yield(i, r.buf[(r.head+i)%len(r.buf)]) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values from front to back.
+func (r *RingBuffer[T]) Values() iter.Seq[T] {
+	return SeqValues(r.All())
+}
+
+// ToSlice copies the values from front to back into a new slice.
+func (r *RingBuffer[T]) ToSlice() []T {
+	result := make([]T, 0, r.size)
+	for _, v := range r.All() {
+		result = append(result, v)
+	}
+	return result
+}
+
+// SPSCRingBuffer is a lock-free bounded FIFO queue for exactly one producer goroutine
+// calling Push and one consumer goroutine calling Pop. Pushing into a full buffer fails.
+type SPSCRingBuffer[T any] struct {
+	buf  []T
+	mask uint64
+	head atomic.Uint64 // next slot to read, owned by the consumer
+	tail atomic.Uint64 // next slot to write, owned by the producer
+}
+
+// NewSPSCRingBuffer returns an empty SPSCRingBuffer whose capacity is capacity rounded up to a power of two.
+func NewSPSCRingBuffer[T any](capacity int) *SPSCRingBuffer[T] {
+	if capacity <= 0 {
+		panic("sugar: ring buffer capacity must be positive")
+	}
+	size := uint64(1)
+	for size < uint64(capacity) {
+		size <<= 1
+	}
+	return &SPSCRingBuffer[T]{
+		buf:  make([]T, size),
+		mask: size - 1,
+	}
 }
 
-func NewRingBuffer(inputChannel <-chan any, outputChannel chan any) *RingBuffer {
-	return &RingBuffer{inputChannel, outputChannel}
+// Push appends v. It must only be called from the producer goroutine.
+func (r *SPSCRingBuffer[T]) Push(v T) bool {
+	tail := r.tail.Load()
+	if tail-r.head.Load() == uint64(len(r.buf)) {
+		return false
+	}
+	r.buf[tail&r.mask] = v
+	r.tail.Store(tail + 1)
+	return true
 }
 
-func (r *RingBuffer) Run() {
-	for v := range r.inputChannel {
+// Pop removes the oldest value. It must only be called from the consumer goroutine.
+func (r *SPSCRingBuffer[T]) Pop() (T, bool) {
+	var zero T
+	head := r.head.Load()
+	if head == r.tail.Load() {
+		return zero, false
+	}
+	v := r.buf[head&r.mask]
+	r.buf[head&r.mask] = zero
+	r.head.Store(head + 1)
+	return v, true
+}
+
+// Len returns the number of values held. It is exact only when called from the producer or consumer.
+func (r *SPSCRingBuffer[T]) Len() int {
+	return int(r.tail.Load() - r.head.Load())
+}
+
+// Cap returns the maximum number of values the buffer can hold.
+func (r *SPSCRingBuffer[T]) Cap() int {
+	return len(r.buf)
+}
+
+// RingRelay forwards values from an input to an output channel through a RingBuffer
+// with the Overwrite policy: when the consumer falls behind the oldest values are
+// dropped, so the consumer always sees the most recent ones.
+type RingRelay[T any] struct {
+	in      <-chan T
+	out     chan<- T
+	buf     *RingBuffer[T]
+	dropped atomic.Uint64
+}
+
+// NewRingRelay returns a RingRelay buffering up to capacity values between in and out.
+func NewRingRelay[T any](in <-chan T, out chan<- T, capacity int) *RingRelay[T] {
+	return &RingRelay[T]{
+		in:  in,
+		out: out,
+		buf: NewRingBuffer[T](capacity, Overwrite),
+	}
+}
+
+// Run relays values until in is closed, then delivers what is still buffered and closes out.
+func (r *RingRelay[T]) Run() {
+	in := r.in
+	for in != nil || !r.buf.IsEmpty() {
+		var out chan<- T
+		front, ok := r.buf.PeekFront()
+		if ok {
+			out = r.out
+		}
+
 		select {
-		case r.outputChannel <- v:
-		default:
-			<-r.outputChannel
-			r.outputChannel <- v
+		case v, open := <-in:
+			if !open {
+				in = nil
+				continue
+			}
+			if _, evicted, _ := r.buf.PushEvict(v); evicted {
+				r.dropped.Add(1)
+			}
+		case out <- front:
+			r.buf.Pop()
 		}
 	}
-	close(r.outputChannel)
+	close(r.out)
+}
+
+// Dropped returns how many values were overwritten before the consumer received them.
+func (r *RingRelay[T]) Dropped() uint64 {
+	return r.dropped.Load()
 }
@@ -1,26 +1,81 @@
-//go:build ignore
-// +build ignore
-
 package sugar
 
 import (
-	"fmt"
+	"runtime"
+	"slices"
+	"sync"
 	"testing"
 )
 
 func TestRingBuffer(t *testing.T) {
-	in := make(chan any)
-	out := make(chan any, 5)
-	rb := NewRingBuffer(in, out)
-	go rb.Run()
+	rb := NewRingBuffer[int](3, Overwrite)
+	for i := 0; i < 5; i++ {
+		rb.Push(i)
+	}
+
+	if got := rb.ToSlice(); !slices.Equal(got, []int{2, 3, 4}) {
+		t.Fatalf("unexpected content %v", got)
+	}
+	if v, _ := rb.PeekBack(); v != 4 {
+		t.Fatalf("unexpected back %d", v)
+	}
+	if v, _ := rb.Pop(); v != 2 || rb.Len() != 2 {
+		t.Fatalf("unexpected pop %d", v)
+	}
+
+	reject := NewRingBuffer[int](1, Reject)
+	if !reject.Push(1) || reject.Push(2) {
+		t.Fatal("expected second push to be rejected")
+	}
+}
+
+func TestSPSCRingBuffer(t *testing.T) {
+	rb := NewSPSCRingBuffer[int](4)
+	const n = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; {
+			if rb.Push(i) {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	for want := 0; want < n; {
+		if v, ok := rb.Pop(); ok {
+			if v != want {
+				t.Fatalf("expected %d, got %d", want, v)
+			}
+			want++
+		} else {
+			runtime.Gosched()
+		}
+	}
+	wg.Wait()
+}
+
+func TestRingRelay(t *testing.T) {
+	in := make(chan int)
+	out := make(chan int)
+	relay := NewRingRelay(in, out, 5)
+	go relay.Run()
 
 	for i := 0; i < 10; i++ {
 		in <- i
 	}
-
 	close(in)
 
-	for res := range out {
-		fmt.Println(res)
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+
+	if len(got)+int(relay.Dropped()) != 10 || got[len(got)-1] != 9 {
+		t.Fatalf("unexpected relay output %v with %d dropped", got, relay.Dropped())
 	}
 }
@@ -0,0 +1,317 @@
+package sugar
+
+import "iter"
+
+// SeqFromSlice returns a lazy sequence over the elements of collection.
+func SeqFromSlice[T any](collection []T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range collection {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// SeqToSlice drains seq into a newly allocated slice.
+func SeqToSlice[T any](seq iter.Seq[T]) []T {
+	result := make([]T, 0)
+
+	for item := range seq {
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// SeqFromMap returns a lazy key/value sequence over the entries of in.
+func SeqFromMap[K comparable, V any](in map[K]V) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range in {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// SeqToMap drains a key/value sequence into a map. Later keys overwrite earlier ones.
+func SeqToMap[K comparable, V any](seq iter.Seq2[K, V]) map[K]V {
+	result := make(map[K]V)
+
+	for k, v := range seq {
+		result[k] = v
+	}
+
+	return result
+}
+
+// SeqEntries turns a key/value sequence into a sequence of Entry values.
+func SeqEntries[K comparable, V any](seq iter.Seq2[K, V]) iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for k, v := range seq {
+			if !yield(Entry[K, V]{k, v}) {
+				return
+			}
+		}
+	}
+}
+
+// SeqFromEntries turns a sequence of Entry values into a key/value sequence.
+func SeqFromEntries[K comparable, V any](seq iter.Seq[Entry[K, V]]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for entry := range seq {
+			if !yield(entry.Key, entry.Value) {
+				return
+			}
+		}
+	}
+}
+
+// SeqKeys returns the keys of a key/value sequence.
+func SeqKeys[K, V any](seq iter.Seq2[K, V]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range seq {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// SeqValues returns the values of a key/value sequence.
+func SeqValues[K, V any](seq iter.Seq2[K, V]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range seq {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// SeqEnumerate pairs every element of seq with its zero-based position.
+func SeqEnumerate[T any](seq iter.Seq[T]) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for item := range seq {
+			if !yield(i, item) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// SeqMap is the lazy counterpart of Map.
+func SeqMap[T any, R any](seq iter.Seq[T], iteratee func(T, int) R) iter.Seq[R] {
+	return func(yield func(R) bool) {
+		i := 0
+		for item := range seq {
+			if !yield(iteratee(item, i)) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// SeqFilter is the lazy counterpart of Filter.
+func SeqFilter[T any](seq iter.Seq[T], predicate func(T, int) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		i := 0
+		for item := range seq {
+			if predicate(item, i) && !yield(item) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// SeqReduce consumes seq and accumulates it the same way Reduce does.
+func SeqReduce[T any, R any](seq iter.Seq[T], accumulator func(R, T, int) R, initial R) R {
+	i := 0
+	for item := range seq {
+		initial = accumulator(initial, item, i)
+		i++
+	}
+
+	return initial
+}
+
+// SeqFind returns the first element of seq predicate returns truthy for.
+func SeqFind[T any](seq iter.Seq[T], predicate func(T) bool) (T, bool) {
+	for item := range seq {
+		if predicate(item) {
+			return item, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// SeqTake yields at most n elements of seq.
+func SeqTake[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+
+		taken := 0
+		for item := range seq {
+			if !yield(item) {
+				return
+			}
+			taken++
+			if taken >= n {
+				return
+			}
+		}
+	}
+}
+
+// SeqTakeWhile yields elements of seq until predicate returns falsy for the first time.
+func SeqTakeWhile[T any](seq iter.Seq[T], predicate func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for item := range seq {
+			if !predicate(item) || !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// SeqDrop skips the first n elements of seq.
+func SeqDrop[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		skipped := 0
+		for item := range seq {
+			if skipped < n {
+				skipped++
+				continue
+			}
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// SeqDropWhile skips elements of seq while predicate returns truthy.
+func SeqDropWhile[T any](seq iter.Seq[T], predicate func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		dropping := true
+		for item := range seq {
+			if dropping && predicate(item) {
+				continue
+			}
+			dropping = false
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// SeqUniq is the lazy counterpart of Uniq.
+func SeqUniq[T comparable](seq iter.Seq[T]) iter.Seq[T] {
+	return SeqUniqBy(seq, func(item T) T { return item })
+}
+
+// SeqUniqBy is the lazy counterpart of UniqBy.
+func SeqUniqBy[T any, U comparable](seq iter.Seq[T], iteratee func(T) U) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		seen := make(map[U]struct{})
+
+		for item := range seq {
+			key := iteratee(item)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// SeqGroupBy consumes seq and groups its elements the same way GroupBy does.
+func SeqGroupBy[T any, U comparable](seq iter.Seq[T], iteratee func(T) U) map[U][]T {
+	result := map[U][]T{}
+
+	for item := range seq {
+		key := iteratee(item)
+		result[key] = append(result[key], item)
+	}
+
+	return result
+}
+
+// SeqChunk groups seq into slices of length size. The last chunk may be shorter.
+// Every yielded chunk is a fresh slice and may be retained by the caller.
+func SeqChunk[T any](seq iter.Seq[T], size int) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		if size <= 0 {
+			return
+		}
+
+		chunk := make([]T, 0, size)
+		for item := range seq {
+			chunk = append(chunk, item)
+			if len(chunk) == size {
+				if !yield(chunk) {
+					return
+				}
+				chunk = make([]T, 0, size)
+			}
+		}
+
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// SeqFlatten flattens a sequence of slices a single level deep.
+func SeqFlatten[T any](seq iter.Seq[[]T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for subCollection := range seq {
+			for _, item := range subCollection {
+				if !yield(item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// SeqConcat yields the elements of every given sequence one after another.
+func SeqConcat[T any](seqs ...iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, seq := range seqs {
+			for item := range seq {
+				if !yield(item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// SeqZip pairs up elements of a and b, stopping as soon as either is exhausted.
+func SeqZip[A any, B any](a iter.Seq[A], b iter.Seq[B]) iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		next, stop := iter.Pull(b)
+		defer stop()
+
+		for itemA := range a {
+			itemB, ok := next()
+			if !ok || !yield(itemA, itemB) {
+				return
+			}
+		}
+	}
+}
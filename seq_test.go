@@ -0,0 +1,48 @@
+package sugar
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSeqPipeline(t *testing.T) {
+	calls := 0
+	seq := SeqFromSlice(Range(0, 1000))
+	seq = SeqMap(seq, func(x int, _ int) int {
+		calls++
+		return x * 2
+	})
+	seq = SeqFilter(seq, func(x int, _ int) bool { return x%3 == 0 })
+
+	got := SeqToSlice(SeqTake(seq, 3))
+	if !slices.Equal(got, []int{0, 6, 12}) {
+		t.Fatalf("unexpected result %v", got)
+	}
+	if calls != 7 {
+		t.Fatalf("pipeline is not lazy, iteratee called %d times", calls)
+	}
+}
+
+func TestSeqChunkFlatten(t *testing.T) {
+	chunks := SeqToSlice(SeqChunk(SeqFromSlice([]int{1, 2, 3, 4, 5}), 2))
+	if len(chunks) != 3 || !slices.Equal(chunks[2], []int{5}) {
+		t.Fatalf("unexpected chunks %v", chunks)
+	}
+
+	flat := SeqToSlice(SeqFlatten(SeqFromSlice(chunks)))
+	if !slices.Equal(flat, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected flatten %v", flat)
+	}
+}
+
+func TestSeqUniqByZip(t *testing.T) {
+	uniq := SeqToSlice(SeqUniqBy(SeqFromSlice([]int{1, 2, 3, 4, 5}), func(x int) int { return x % 2 }))
+	if !slices.Equal(uniq, []int{1, 2}) {
+		t.Fatalf("unexpected uniq %v", uniq)
+	}
+
+	zipped := SeqToMap(SeqZip(SeqFromSlice([]string{"a", "b", "c"}), SeqFromSlice([]int{1, 2})))
+	if len(zipped) != 2 || zipped["a"] != 1 || zipped["b"] != 2 {
+		t.Fatalf("unexpected zip %v", zipped)
+	}
+}
@@ -265,31 +265,6 @@ func AsyncErr[T any](callback func() (T, error)) (<-chan T, <-chan error) {
 	return ch, errCh
 }
 
-// DebounceFunc creates a debounced function that delays invoking the callback.
-func DebounceFunc[T any](callback func(...T), delay time.Duration) func(...T) {
-	var timer *time.Timer
-	return func(args ...T) {
-		if timer != nil {
-			timer.Stop()
-		}
-		timer = time.AfterFunc(delay, func() {
-			callback(args...)
-		})
-	}
-}
-
-// Throttle creates a throttled function that only invokes the callback at most once per duration.
-func Throttle[T any](callback func(...T), duration time.Duration) func(...T) {
-	var lastCall time.Time
-	return func(args ...T) {
-		now := time.Now()
-		if now.Sub(lastCall) >= duration {
-			lastCall = now
-			callback(args...)
-		}
-	}
-}
-
 // Times invokes the callback n times, returning an array of the results.
 func Times[T any](count int, callback func(int) T) []T {
 	if count <= 0 {
@@ -316,43 +291,6 @@ func Repeat[T any](count int, initial T) []T {
 	return result
 }
 
-// Optional represents an optional value.
-type Optional[T any] struct {
-	value   T
-	present bool
-}
-
-// IsPresent returns true if the optional contains a value.
-func (o *Optional[T]) IsPresent() bool {
-	return o.present
-}
-
-// IsEmpty returns true if the optional is empty.
-func (o *Optional[T]) IsEmpty() bool {
-	return !o.present
-}
-
-// Get returns the value if present, otherwise returns zero value.
-func (o *Optional[T]) Get() T {
-	return o.value
-}
-
-// OrElse returns the value if present, otherwise returns the fallback.
-func (o *Optional[T]) OrElse(fallback T) T {
-	if o.present {
-		return o.value
-	}
-	return fallback
-}
-
-// OrElseF returns the value if present, otherwise calls the fallback function.
-func (o *Optional[T]) OrElseF(callback func() T) T {
-	if o.present {
-		return o.value
-	}
-	return callback()
-}
-
 // SwitchCaseStruct represents a switch-case structure.
 type SwitchCaseStruct[T comparable, R any] struct {
 	predicate T